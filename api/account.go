@@ -2,6 +2,7 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -9,35 +10,64 @@ import (
 )
 
 func (s *Server) initAccountRoutes() {
-	s.router.POST("/accounts", s.createAccount)
-	s.router.GET("/accounts/:id", s.getAccount)
-	s.router.GET("/accounts", s.listAccount)
+	authRoutes := s.router.Group("/").Use(authMiddleware(s.tokenMaker))
+	authRoutes.POST("/accounts", s.createAccount)
+	authRoutes.GET("/accounts/:id", s.getAccount)
+	authRoutes.GET("/accounts", s.listAccount)
 }
 
 type createAccountRequest struct {
-	Username string `json:"username" binding:"required"`
-	Currency string `json:"currency" binding:"required,oneof=USD TWD"`
+	Currency string `json:"currency" binding:"required,currency"`
 }
 
 func (s *Server) createAccount(c *gin.Context) {
 	var req createAccountRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse(err))
+		abortWithError(c, bindingError(err))
 		return
 	}
 
+	payload := authPayload(c)
 	arg := db.CreateAccountParams{
-		Username: req.Username,
+		Username: payload.Username,
 		Balance:  0,
 		Currency: req.Currency,
 	}
-	account, err := s.store.CreateAccount(c, arg)
+	account, err := s.store.CreateAccount(c.Request.Context(), arg)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		abortWithError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, account)
+	c.JSON(http.StatusOK, s.newAccountResponse(account))
+}
+
+// accountResponse is the account along with its balance rendered in the account currency
+type accountResponse struct {
+	db.Account
+	FormattedBalance string
+}
+
+func (s *Server) newAccountResponse(account db.Account) accountResponse {
+	return accountResponse{
+		Account:          account,
+		FormattedBalance: s.formatAmount(account.Currency, account.Balance),
+	}
+}
+
+func (s *Server) newAccountResponses(accounts []db.Account) []accountResponse {
+	resp := make([]accountResponse, len(accounts))
+	for i, account := range accounts {
+		resp[i] = s.newAccountResponse(account)
+	}
+	return resp
+}
+
+// accountDetailResponse is the account along with the balance not reserved by active holds
+type accountDetailResponse struct {
+	accountResponse
+	AvailableBalance          int64
+	FormattedAvailableBalance string
 }
 
 type getAccountRequest struct {
@@ -47,44 +77,88 @@ type getAccountRequest struct {
 func (s *Server) getAccount(c *gin.Context) {
 	var req getAccountRequest
 	if err := c.ShouldBindUri(&req); err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse(err))
+		abortWithError(c, bindingError(err))
 		return
 	}
 
-	account, err := s.store.GetAccount(c, req.ID)
+	account, err := s.store.GetAccount(c.Request.Context(), req.ID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, errorResponse(err))
-			return
-		} else {
-			c.JSON(http.StatusInternalServerError, errorResponse(err))
-			return
+		if errors.Is(err, sql.ErrNoRows) {
+			err = notFoundError(errCodeAccountNotFound, "account")
 		}
+		abortWithError(c, err)
+		return
+	}
+
+	payload := authPayload(c)
+	if account.Username != payload.Username {
+		abortWithError(c, forbiddenError("account doesn't belong to the authenticated user"))
+		return
 	}
-	c.JSON(http.StatusOK, account)
+
+	heldAmount, err := s.store.GetAccountHeldAmount(c.Request.Context(), account.ID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	availableBalance := account.Balance - heldAmount
+	c.JSON(http.StatusOK, accountDetailResponse{
+		accountResponse:           s.newAccountResponse(account),
+		AvailableBalance:          availableBalance,
+		FormattedAvailableBalance: s.formatAmount(account.Currency, availableBalance),
+	})
 }
 
 type listAccountRequest struct {
-	PageID   int32 `form:"page_id" binding:"required,min=1"`
-	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+	pageRequest
 }
 
 func (s *Server) listAccount(c *gin.Context) {
 	var req listAccountRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse(err))
+		abortWithError(c, bindingError(err))
 		return
 	}
 
-	arg := db.ListAccountsParams{
-		Limit:  req.PageSize,
-		Offset: (req.PageID - 1) * req.PageSize,
+	payload := authPayload(c)
+	if req.useOffset() {
+		arg := db.ListAccountsByUsernameParams{
+			Username: payload.Username,
+			Limit:    req.PageSize,
+			Offset:   req.offset(),
+		}
+		accounts, err := s.store.ListAccountsByUsername(c.Request.Context(), arg)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, s.newAccountResponses(accounts))
+		return
 	}
-	accounts, err := s.store.ListAccounts(c, arg)
+
+	cursor, err := decodeCursor(req.Cursor)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		abortWithError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, accounts)
+	// fetch one extra row to find out whether there is a next page
+	arg := db.ListAccountsByUsernameAfterParams{
+		Username: payload.Username,
+		Cursor:   cursor,
+		Limit:    req.PageSize + 1,
+	}
+	accounts, err := s.store.ListAccountsByUsernameAfter(c.Request.Context(), arg)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	var resp pageResponse
+	if len(accounts) > int(req.PageSize) {
+		accounts = accounts[:req.PageSize]
+		resp.NextCursor = encodeCursor(accounts[len(accounts)-1].ID)
+	}
+	resp.Items = s.newAccountResponses(accounts)
+	c.JSON(http.StatusOK, resp)
 }
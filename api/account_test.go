@@ -10,29 +10,36 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
+	"github.com/lib/pq"
 	mockdb "github.com/peienxie/go-bank/db/mock"
 	db "github.com/peienxie/go-bank/db/sqlc"
+	"github.com/peienxie/go-bank/token"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestCreateAccountAPI(t *testing.T) {
-	account := randomAccount()
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
 
 	testCases := []struct {
 		name          string
 		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
 		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
 		{
 			"OK",
 			gin.H{
-				"username": account.Username,
 				"currency": account.Currency,
 			},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
 			func(store *mockdb.MockStore) {
 				arg := db.CreateAccountParams{
 					Username: account.Username,
@@ -50,10 +57,50 @@ func TestCreateAccountAPI(t *testing.T) {
 			},
 		},
 		{
-			"BadRequest missing username field",
+			"OK registered currency",
+			gin.H{
+				"currency": "EUR",
+			},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				arg := db.CreateAccountParams{
+					Username: account.Username,
+					Currency: "EUR",
+					Balance:  0,
+				}
+				store.EXPECT().
+					CreateAccount(gomock.Any(), arg).
+					Times(1).
+					Return(account, nil)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			"Unauthorized no authorization",
 			gin.H{
 				"currency": account.Currency,
 			},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			"BadRequest missing currency field",
+			gin.H{},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
 			func(store *mockdb.MockStore) {
 				store.EXPECT().
 					CreateAccount(gomock.Any(), gomock.Any()).
@@ -66,9 +113,11 @@ func TestCreateAccountAPI(t *testing.T) {
 		{
 			"BadRequest currency invalid",
 			gin.H{
-				"username": account.Username,
 				"currency": "QWE",
 			},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
 			func(store *mockdb.MockStore) {
 				store.EXPECT().
 					CreateAccount(gomock.Any(), gomock.Any()).
@@ -78,12 +127,33 @@ func TestCreateAccountAPI(t *testing.T) {
 				assert.Equal(t, http.StatusBadRequest, recorder.Code)
 			},
 		},
+		{
+			"UserNotExists",
+			gin.H{
+				"currency": account.Currency,
+			},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateAccount(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Account{}, &pq.Error{Code: "23503"})
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				checkErrorCode(t, recorder.Body, errCodeInvalidReference)
+			},
+		},
 		{
 			"InternalError",
 			gin.H{
-				"username": account.Username,
 				"currency": account.Currency,
 			},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
 			func(store *mockdb.MockStore) {
 				arg := db.CreateAccountParams{
 					Username: account.Username,
@@ -108,7 +178,7 @@ func TestCreateAccountAPI(t *testing.T) {
 			store := mockdb.NewMockStore(ctrl)
 			tc.buildStubs(store)
 
-			server := NewServer(store)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 
 			data, err := json.Marshal(tc.body)
@@ -118,6 +188,7 @@ func TestCreateAccountAPI(t *testing.T) {
 			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
 			assert.NoError(t, err)
 
+			tc.setupAuth(t, request, server.tokenMaker)
 			server.router.ServeHTTP(recorder, request)
 			tc.checkResponse(t, recorder)
 		})
@@ -125,31 +196,102 @@ func TestCreateAccountAPI(t *testing.T) {
 }
 
 func TestGetAccountAPI(t *testing.T) {
-	account := randomAccount()
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	account.Currency = "USD"
+	account.Balance = 12345
 
 	testCases := []struct {
 		name          string
 		accountID     int64
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
 		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
 		{
 			"OK",
 			account.ID,
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
 			func(store *mockdb.MockStore) {
 				store.EXPECT().
 					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
 					Times(1).
 					Return(account, nil)
+				store.EXPECT().
+					GetAccountHeldAmount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(int64(30), nil)
 			},
 			func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				assert.Equal(t, http.StatusOK, recorder.Code)
-				checkAccountResponse(t, recorder.Body, account)
+
+				var got accountDetailResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				assert.NoError(t, err)
+				assert.Equal(t, account, got.Account)
+				assert.Equal(t, account.Balance-30, got.AvailableBalance)
+				assert.Equal(t, "$123.45", got.FormattedBalance)
+				assert.Equal(t, "$123.15", got.FormattedAvailableBalance)
+			},
+		},
+		{
+			"InternalError held amount",
+			account.ID,
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					GetAccountHeldAmount(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), sql.ErrConnDone)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			"Unauthorized no authorization",
+			account.ID,
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			"Forbidden unauthorized user",
+			account.ID,
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusForbidden, recorder.Code)
 			},
 		},
 		{
 			"NotFound",
 			account.ID,
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
 			func(store *mockdb.MockStore) {
 				store.EXPECT().
 					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
@@ -158,11 +300,15 @@ func TestGetAccountAPI(t *testing.T) {
 			},
 			func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				assert.Equal(t, http.StatusNotFound, recorder.Code)
+				checkErrorCode(t, recorder.Body, errCodeAccountNotFound)
 			},
 		},
 		{
 			"InternalError",
 			account.ID,
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
 			func(store *mockdb.MockStore) {
 				store.EXPECT().
 					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
@@ -171,11 +317,15 @@ func TestGetAccountAPI(t *testing.T) {
 			},
 			func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+				checkErrorCode(t, recorder.Body, errCodeInternal)
 			},
 		},
 		{
 			"BadRequest",
 			-1,
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
 			func(store *mockdb.MockStore) {
 				store.EXPECT().
 					GetAccount(gomock.Any(), gomock.Any()).
@@ -183,6 +333,7 @@ func TestGetAccountAPI(t *testing.T) {
 			},
 			func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+				checkErrorCode(t, recorder.Body, errCodeValidationFailed)
 			},
 		},
 	}
@@ -195,13 +346,14 @@ func TestGetAccountAPI(t *testing.T) {
 			store := mockdb.NewMockStore(ctrl)
 			tc.buildStubs(store)
 
-			server := NewServer(store)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 
 			url := fmt.Sprintf("/accounts/%d", tc.accountID)
 			request, err := http.NewRequest(http.MethodGet, url, nil)
 			assert.NoError(t, err)
 
+			tc.setupAuth(t, request, server.tokenMaker)
 			server.router.ServeHTTP(recorder, request)
 			tc.checkResponse(t, recorder)
 		})
@@ -210,22 +362,29 @@ func TestGetAccountAPI(t *testing.T) {
 }
 
 func TestListAccountAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
 	testCases := []struct {
 		name          string
 		queryParam    gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
 		buildStubs    func(store *mockdb.MockStore, pageID, pageSize int32)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
 		{
 			"OK",
 			gin.H{"page_id": 1, "page_size": 10},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
 			func(store *mockdb.MockStore, pageID, pageSize int32) {
-				arg := db.ListAccountsParams{
-					Limit:  pageSize,
-					Offset: (pageID - 1) * pageSize,
+				arg := db.ListAccountsByUsernameParams{
+					Username: user.Username,
+					Limit:    pageSize,
+					Offset:   (pageID - 1) * pageSize,
 				}
 				store.EXPECT().
-					ListAccounts(gomock.Any(), gomock.Eq(arg)).
+					ListAccountsByUsername(gomock.Any(), gomock.Eq(arg)).
 					Times(1).
 					Return(nil, nil)
 			},
@@ -233,12 +392,29 @@ func TestListAccountAPI(t *testing.T) {
 				assert.Equal(t, http.StatusOK, recorder.Code)
 			},
 		},
+		{
+			"Unauthorized no authorization",
+			gin.H{"page_id": 1, "page_size": 10},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			func(store *mockdb.MockStore, pageID, pageSize int32) {
+				store.EXPECT().
+					ListAccountsByUsername(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
 		{
 			"BadRequest page id invalid",
-			gin.H{"page_size": 10},
+			gin.H{"page_id": 0, "page_size": 10},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
 			func(store *mockdb.MockStore, pageID, pageSize int32) {
 				store.EXPECT().
-					ListAccounts(gomock.Any(), gomock.Any()).
+					ListAccountsByUsername(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -248,9 +424,12 @@ func TestListAccountAPI(t *testing.T) {
 		{
 			"BadRequest page size is less than min value",
 			gin.H{"page_id": 1, "page_size": 2},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
 			func(store *mockdb.MockStore, pageID, pageSize int32) {
 				store.EXPECT().
-					ListAccounts(gomock.Any(), gomock.Any()).
+					ListAccountsByUsername(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -260,9 +439,12 @@ func TestListAccountAPI(t *testing.T) {
 		{
 			"BadRequest page size is greater than max value",
 			gin.H{"page_id": 1, "page_size": 20},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
 			func(store *mockdb.MockStore, pageID, pageSize int32) {
 				store.EXPECT().
-					ListAccounts(gomock.Any(), gomock.Any()).
+					ListAccountsByUsername(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -272,9 +454,12 @@ func TestListAccountAPI(t *testing.T) {
 		{
 			"InternalError",
 			gin.H{"page_id": 1, "page_size": 10},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
 			func(store *mockdb.MockStore, pageID, pageSize int32) {
 				store.EXPECT().
-					ListAccounts(gomock.Any(), gomock.Any()).
+					ListAccountsByUsername(gomock.Any(), gomock.Any()).
 					Times(1).
 					Return(nil, sql.ErrConnDone)
 			},
@@ -300,7 +485,7 @@ func TestListAccountAPI(t *testing.T) {
 			}
 			tc.buildStubs(store, page_id, page_size)
 
-			server := NewServer(store)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 
 			q := url.Values{}
@@ -311,6 +496,7 @@ func TestListAccountAPI(t *testing.T) {
 			request, err := http.NewRequest(http.MethodGet, url, nil)
 			assert.NoError(t, err)
 
+			tc.setupAuth(t, request, server.tokenMaker)
 			server.router.ServeHTTP(recorder, request)
 			tc.checkResponse(t, recorder)
 		})
@@ -318,10 +504,10 @@ func TestListAccountAPI(t *testing.T) {
 
 }
 
-func randomAccount() db.Account {
+func randomAccount(username string) db.Account {
 	return db.Account{
 		ID:       randomInt(1, 1000),
-		Username: randomUsername(),
+		Username: username,
 		Balance:  randomMoney(),
 		Currency: randomCurrency(),
 	}
@@ -336,3 +522,139 @@ func checkAccountResponse(t *testing.T, body *bytes.Buffer, account db.Account)
 	assert.NoError(t, err)
 	assert.Equal(t, account, gotAccount)
 }
+
+func TestListAccountCursorAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	pageSize := 5
+
+	accounts := make([]db.Account, pageSize+1)
+	for i := range accounts {
+		accounts[i] = randomAccount(user.Username)
+		accounts[i].ID = int64(i + 1)
+	}
+
+	testCases := []struct {
+		name          string
+		queryParam    gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			"OK first page",
+			gin.H{"page_size": pageSize},
+			func(store *mockdb.MockStore) {
+				arg := db.ListAccountsByUsernameAfterParams{
+					Username: user.Username,
+					Cursor:   0,
+					Limit:    int32(pageSize + 1),
+				}
+				store.EXPECT().
+					ListAccountsByUsernameAfter(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(accounts, nil)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccountPage(t, recorder.Body, accounts[:pageSize], encodeCursor(accounts[pageSize-1].ID))
+			},
+		},
+		{
+			"OK last page",
+			gin.H{"cursor": encodeCursor(accounts[pageSize-1].ID), "page_size": pageSize},
+			func(store *mockdb.MockStore) {
+				arg := db.ListAccountsByUsernameAfterParams{
+					Username: user.Username,
+					Cursor:   accounts[pageSize-1].ID,
+					Limit:    int32(pageSize + 1),
+				}
+				store.EXPECT().
+					ListAccountsByUsernameAfter(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(accounts[pageSize:], nil)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccountPage(t, recorder.Body, accounts[pageSize:], "")
+			},
+		},
+		{
+			"BadRequest invalid cursor",
+			gin.H{"cursor": "invalid", "page_size": pageSize},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAccountsByUsernameAfter(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			"BadRequest both page id and cursor",
+			gin.H{"page_id": 1, "cursor": encodeCursor(1), "page_size": pageSize},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAccountsByUsername(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					ListAccountsByUsernameAfter(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			"InternalError",
+			gin.H{"page_size": pageSize},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAccountsByUsernameAfter(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			q := url.Values{}
+			for k, v := range tc.queryParam {
+				q.Add(k, fmt.Sprint(v))
+			}
+			url := fmt.Sprintf("/accounts?%s", q.Encode())
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			assert.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func requireBodyMatchAccountPage(t *testing.T, body *bytes.Buffer, accounts []db.Account, nextCursor string) {
+	data, err := ioutil.ReadAll(body)
+	assert.NoError(t, err)
+
+	var gotPage struct {
+		Items      []db.Account `json:"items"`
+		NextCursor string       `json:"next_cursor"`
+	}
+	err = json.Unmarshal(data, &gotPage)
+	assert.NoError(t, err)
+	assert.Equal(t, accounts, gotPage.Items)
+	assert.Equal(t, nextCursor, gotPage.NextCursor)
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/peienxie/go-bank/db/sqlc"
+	"github.com/peienxie/go-bank/logging"
+	"go.uber.org/zap"
+)
+
+// initCashRoutes registers the cash desk routes. Cash comes into and leaves the bank
+// over the counter, so only an operator can record a deposit or a withdrawal;
+// letting a customer deposit to their own account would create money from nothing
+func (s *Server) initCashRoutes() {
+	operatorRoutes := s.router.Group("/operator").Use(authMiddleware(s.tokenMaker), operatorMiddleware(s.store))
+	operatorRoutes.POST("/accounts/:id/deposits", s.createDeposit)
+	operatorRoutes.POST("/accounts/:id/withdrawals", s.createWithdrawal)
+}
+
+type cashAccountRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type cashRequest struct {
+	Amount   int64  `json:"amount" binding:"required,gt=0"`
+	Currency string `json:"currency" binding:"required,currency"`
+}
+
+// cashResponse is the account after the deposit or withdrawal along with the entry recording it
+type cashResponse struct {
+	Account accountResponse `json:"account"`
+	Entry   entryResponse   `json:"entry"`
+}
+
+func (s *Server) newCashResponse(account db.Account, entry db.Entry) cashResponse {
+	return cashResponse{
+		Account: s.newAccountResponse(account),
+		Entry:   s.newEntryResponse(entry, account.Currency),
+	}
+}
+
+func (s *Server) createDeposit(c *gin.Context) {
+	accountID, req, ok := s.bindCashRequest(c)
+	if !ok {
+		return
+	}
+
+	logging.FromContext(c.Request.Context()).Info("operator depositing cash",
+		zap.String("operator", authPayload(c).Username),
+		zap.Int64("account_id", accountID),
+		zap.Int64("amount", req.Amount),
+	)
+
+	arg := db.DepositTxParams{
+		AccountID: accountID,
+		Amount:    req.Amount,
+	}
+	result, err := s.store.DepositTx(c.Request.Context(), arg)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, s.newCashResponse(result.Account, result.Entry))
+}
+
+func (s *Server) createWithdrawal(c *gin.Context) {
+	accountID, req, ok := s.bindCashRequest(c)
+	if !ok {
+		return
+	}
+
+	logging.FromContext(c.Request.Context()).Info("operator withdrawing cash",
+		zap.String("operator", authPayload(c).Username),
+		zap.Int64("account_id", accountID),
+		zap.Int64("amount", req.Amount),
+	)
+
+	arg := db.WithdrawTxParams{
+		AccountID: accountID,
+		Amount:    req.Amount,
+	}
+	result, err := s.store.WithdrawTx(c.Request.Context(), arg)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, s.newCashResponse(result.Account, result.Entry))
+}
+
+// bindCashRequest binds the deposit or withdrawal request and checks the account
+// exists with the requested currency
+func (s *Server) bindCashRequest(c *gin.Context) (int64, cashRequest, bool) {
+	var uri cashAccountRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		abortWithError(c, bindingError(err))
+		return 0, cashRequest{}, false
+	}
+
+	var req cashRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, bindingError(err))
+		return 0, cashRequest{}, false
+	}
+
+	account, ok := s.validAccount(c, uri.ID, req.Currency)
+	if !ok {
+		return 0, cashRequest{}, false
+	}
+
+	return account.ID, req, true
+}
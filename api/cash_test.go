@@ -0,0 +1,342 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/peienxie/go-bank/db/mock"
+	db "github.com/peienxie/go-bank/db/sqlc"
+	"github.com/peienxie/go-bank/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateDepositAPI(t *testing.T) {
+	amount := randomInt(1, 1000)
+	operator, _ := randomUser(t)
+	operator.Role = db.UserRoleOperator
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	account.Currency = "USD"
+	deposited := account
+	deposited.Balance += amount
+	entry := db.Entry{
+		ID:           randomInt(1, 1000),
+		AccountID:    account.ID,
+		Amount:       amount,
+		Type:         db.EntryTypeDeposit,
+		BalanceAfter: deposited.Balance,
+	}
+
+	testCases := []struct {
+		name          string
+		accountID     int64
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			"OK",
+			account.ID,
+			gin.H{"amount": amount, "currency": "USD"},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, operator.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), operator.Username).Times(1).Return(operator, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				arg := db.DepositTxParams{
+					AccountID: account.ID,
+					Amount:    amount,
+				}
+				store.EXPECT().DepositTx(gomock.Any(), arg).Times(1).
+					Return(db.DepositTxResult{Account: deposited, Entry: entry}, nil)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+
+				var got cashResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				assert.NoError(t, err)
+				assert.Equal(t, deposited, got.Account.Account)
+				assert.Equal(t, entry.ID, got.Entry.ID)
+				assert.Equal(t, entry.Amount, got.Entry.Amount)
+				assert.Equal(t, entry.Type, got.Entry.Type)
+				assert.Equal(t, deposited.Balance, got.Entry.RunningBalance)
+				assert.Nil(t, got.Entry.TransferID)
+			},
+		},
+		{
+			"Unauthorized no authorization",
+			account.ID,
+			gin.H{"amount": amount, "currency": "USD"},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			"BadRequest account id invalid",
+			-1,
+			gin.H{"amount": amount, "currency": "USD"},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, operator.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), operator.Username).Times(1).Return(operator, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			"BadRequest amount invalid",
+			account.ID,
+			gin.H{"amount": -1, "currency": "USD"},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, operator.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), operator.Username).Times(1).Return(operator, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			"BadRequest currency not match",
+			account.ID,
+			gin.H{"amount": amount, "currency": "TWD"},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, operator.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), operator.Username).Times(1).Return(operator, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			"NotFound account not found",
+			account.ID,
+			gin.H{"amount": amount, "currency": "USD"},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, operator.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), operator.Username).Times(1).Return(operator, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			// a customer can't deposit even to their own account
+			"Forbidden customer",
+			account.ID,
+			gin.H{"amount": amount, "currency": "USD"},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), user.Username).Times(1).Return(user, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusForbidden, recorder.Code)
+				checkErrorCode(t, recorder.Body, errCodeForbidden)
+			},
+		},
+		{
+			"InternalError",
+			account.ID,
+			gin.H{"amount": amount, "currency": "USD"},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, operator.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), operator.Username).Times(1).Return(operator, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(1).Return(db.DepositTxResult{}, sql.ErrConnDone)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			assert.NoError(t, err)
+
+			url := fmt.Sprintf("/operator/accounts/%d/deposits", tc.accountID)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			assert.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestCreateWithdrawalAPI(t *testing.T) {
+	amount := randomInt(1, 1000)
+	operator, _ := randomUser(t)
+	operator.Role = db.UserRoleOperator
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	account.Currency = "USD"
+	account.Balance = amount + randomMoney()
+	withdrawn := account
+	withdrawn.Balance -= amount
+	entry := db.Entry{
+		ID:           randomInt(1, 1000),
+		AccountID:    account.ID,
+		Amount:       -amount,
+		Type:         db.EntryTypeWithdrawal,
+		BalanceAfter: withdrawn.Balance,
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			"OK",
+			gin.H{"amount": amount, "currency": "USD"},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, operator.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), operator.Username).Times(1).Return(operator, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				arg := db.WithdrawTxParams{
+					AccountID: account.ID,
+					Amount:    amount,
+				}
+				store.EXPECT().WithdrawTx(gomock.Any(), arg).Times(1).
+					Return(db.WithdrawTxResult{Account: withdrawn, Entry: entry}, nil)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+
+				var got cashResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				assert.NoError(t, err)
+				assert.Equal(t, withdrawn, got.Account.Account)
+				assert.Equal(t, entry.ID, got.Entry.ID)
+				assert.Equal(t, entry.Amount, got.Entry.Amount)
+				assert.Equal(t, entry.Type, got.Entry.Type)
+				assert.Equal(t, withdrawn.Balance, got.Entry.RunningBalance)
+				assert.Nil(t, got.Entry.TransferID)
+			},
+		},
+		{
+			// a customer can't withdraw cash without going through the counter
+			"Forbidden customer",
+			gin.H{"amount": amount, "currency": "USD"},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), user.Username).Times(1).Return(user, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().WithdrawTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusForbidden, recorder.Code)
+				checkErrorCode(t, recorder.Body, errCodeForbidden)
+			},
+		},
+		{
+			"UnprocessableEntity insufficient funds",
+			gin.H{"amount": amount, "currency": "USD"},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, operator.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), operator.Username).Times(1).Return(operator, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				store.EXPECT().WithdrawTx(gomock.Any(), gomock.Any()).Times(1).Return(db.WithdrawTxResult{}, db.ErrInsufficientFunds)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				checkErrorCode(t, recorder.Body, errCodeInsufficientFunds)
+			},
+		},
+		{
+			"InternalError",
+			gin.H{"amount": amount, "currency": "USD"},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, operator.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), operator.Username).Times(1).Return(operator, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				store.EXPECT().WithdrawTx(gomock.Any(), gomock.Any()).Times(1).Return(db.WithdrawTxResult{}, sql.ErrConnDone)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			assert.NoError(t, err)
+
+			url := fmt.Sprintf("/operator/accounts/%d/withdrawals", account.ID)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			assert.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package api
+
+import (
+	"github.com/peienxie/go-bank/currency"
+)
+
+// formatAmount renders the amount in minor units with the decimal places and symbol
+// of the currency, a currency missing from the registry is rendered in minor units
+func (s *Server) formatAmount(code string, amount int64) string {
+	cur, ok := s.currencies.Get(code)
+	if !ok {
+		cur = currency.Currency{Code: code}
+	}
+	return cur.FormatAmount(amount)
+}
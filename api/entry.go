@@ -0,0 +1,163 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/peienxie/go-bank/db/sqlc"
+)
+
+func (s *Server) initEntryRoutes() {
+	authRoutes := s.router.Group("/").Use(authMiddleware(s.tokenMaker))
+	authRoutes.GET("/accounts/:id/entries", s.listAccountEntries)
+}
+
+// entryResponse is an entry of the account along with the balance after it. TransferID and
+// CounterpartyAccountID are only present for entries created by a transfer
+type entryResponse struct {
+	ID                    int64
+	AccountID             int64
+	Amount                int64
+	CreatedAt             time.Time
+	Type                  db.EntryType
+	TransferID            *int64 `json:",omitempty"`
+	CounterpartyAccountID *int64 `json:",omitempty"`
+	RunningBalance        int64
+	// the amounts rendered in the account currency
+	FormattedAmount         string
+	FormattedRunningBalance string
+}
+
+func (s *Server) newEntryResponse(entry db.Entry, currency string) entryResponse {
+	resp := entryResponse{
+		ID:                      entry.ID,
+		AccountID:               entry.AccountID,
+		Amount:                  entry.Amount,
+		CreatedAt:               entry.CreatedAt,
+		Type:                    entry.Type,
+		RunningBalance:          entry.BalanceAfter,
+		FormattedAmount:         s.formatAmount(currency, entry.Amount),
+		FormattedRunningBalance: s.formatAmount(currency, entry.BalanceAfter),
+	}
+	if entry.TransferID.Valid {
+		resp.TransferID = &entry.TransferID.Int64
+	}
+	return resp
+}
+
+// newStatementEntryResponse converts an entry of the account statement, which comes with its counterparty
+func (s *Server) newStatementEntryResponse(row db.ListAccountEntriesRow, currency string) entryResponse {
+	resp := s.newEntryResponse(db.Entry{
+		ID:           row.ID,
+		AccountID:    row.AccountID,
+		Amount:       row.Amount,
+		CreatedAt:    row.CreatedAt,
+		TransferID:   row.TransferID,
+		Type:         row.Type,
+		BalanceAfter: row.BalanceAfter,
+	}, currency)
+	if row.CounterpartyAccountID.Valid {
+		resp.CounterpartyAccountID = &row.CounterpartyAccountID.Int64
+	}
+	return resp
+}
+
+type listAccountEntriesURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type listAccountEntriesRequest struct {
+	pageRequest
+	From time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To   time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00" binding:"omitempty,gtfield=From"`
+}
+
+func (s *Server) listAccountEntries(c *gin.Context) {
+	var uri listAccountEntriesURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	var req listAccountEntriesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	account, err := s.store.GetAccount(c.Request.Context(), uri.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = notFoundError(errCodeAccountNotFound, "account")
+		}
+		abortWithError(c, err)
+		return
+	}
+
+	payload := authPayload(c)
+	if account.Username != payload.Username {
+		abortWithError(c, forbiddenError("account doesn't belong to the authenticated user"))
+		return
+	}
+
+	// the statement ends at the current time if no end time is provided
+	if req.To.IsZero() {
+		req.To = time.Now()
+	}
+
+	if req.useOffset() {
+		arg := db.ListAccountEntriesParams{
+			AccountID: account.ID,
+			FromTime:  req.From,
+			ToTime:    req.To,
+			Limit:     req.PageSize,
+			Offset:    req.offset(),
+		}
+		rows, err := s.store.ListAccountEntries(c.Request.Context(), arg)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		entries := make([]entryResponse, len(rows))
+		for i, row := range rows {
+			entries[i] = s.newStatementEntryResponse(row, account.Currency)
+		}
+		c.JSON(http.StatusOK, entries)
+		return
+	}
+
+	cursor, err := decodeCursor(req.Cursor)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	// fetch one extra row to find out whether there is a next page
+	arg := db.ListAccountEntriesAfterParams{
+		AccountID: account.ID,
+		FromTime:  req.From,
+		ToTime:    req.To,
+		Cursor:    cursor,
+		Limit:     req.PageSize + 1,
+	}
+	rows, err := s.store.ListAccountEntriesAfter(c.Request.Context(), arg)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	entries := make([]entryResponse, len(rows))
+	for i, row := range rows {
+		entries[i] = s.newStatementEntryResponse(db.ListAccountEntriesRow(row), account.Currency)
+	}
+
+	resp := pageResponse{Items: entries}
+	if len(entries) > int(req.PageSize) {
+		entries = entries[:req.PageSize]
+		resp.Items = entries
+		resp.NextCursor = encodeCursor(entries[len(entries)-1].ID)
+	}
+	c.JSON(http.StatusOK, resp)
+}
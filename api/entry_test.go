@@ -0,0 +1,272 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/peienxie/go-bank/db/mock"
+	db "github.com/peienxie/go-bank/db/sqlc"
+	"github.com/peienxie/go-bank/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListAccountEntriesAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	account.Currency = "TWD"
+
+	from := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		queryParam    gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			"OK",
+			gin.H{
+				"from":      from.Format(time.RFC3339),
+				"to":        to.Format(time.RFC3339),
+				"page_id":   2,
+				"page_size": 5,
+			},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				arg := db.ListAccountEntriesParams{
+					AccountID: account.ID,
+					FromTime:  from,
+					ToTime:    to,
+					Limit:     5,
+					Offset:    5,
+				}
+				store.EXPECT().ListAccountEntries(gomock.Any(), arg).Times(1).Return(nil, nil)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			"OK without time range",
+			gin.H{"page_id": 1, "page_size": 5},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Any()).Times(1).Return(nil, nil)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			"OK transfer entry with counterparty",
+			gin.H{"page_id": 1, "page_size": 5},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				rows := []db.ListAccountEntriesRow{
+					{ID: 1, AccountID: account.ID, Amount: 100, Type: db.EntryTypeDeposit, BalanceAfter: 100},
+					{
+						ID:                    2,
+						AccountID:             account.ID,
+						Amount:                -30,
+						Type:                  db.EntryTypeTransfer,
+						TransferID:            sql.NullInt64{Int64: 7, Valid: true},
+						CounterpartyAccountID: sql.NullInt64{Int64: 9, Valid: true},
+						BalanceAfter:          70,
+					},
+				}
+				store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Any()).Times(1).Return(rows, nil)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+
+				var entries []map[string]interface{}
+				err := json.Unmarshal(recorder.Body.Bytes(), &entries)
+				assert.NoError(t, err)
+				assert.Len(t, entries, 2)
+
+				assert.Equal(t, "deposit", entries[0]["Type"])
+				assert.NotContains(t, entries[0], "TransferID")
+				assert.NotContains(t, entries[0], "CounterpartyAccountID")
+
+				assert.Equal(t, "transfer", entries[1]["Type"])
+				assert.Equal(t, float64(7), entries[1]["TransferID"])
+				assert.Equal(t, float64(9), entries[1]["CounterpartyAccountID"])
+				assert.Equal(t, "-NT$0.30", entries[1]["FormattedAmount"])
+				assert.Equal(t, "NT$0.70", entries[1]["FormattedRunningBalance"])
+			},
+		},
+		{
+			"OK cursor",
+			gin.H{
+				"from":      from.Format(time.RFC3339),
+				"to":        to.Format(time.RFC3339),
+				"cursor":    encodeCursor(42),
+				"page_size": 5,
+			},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				arg := db.ListAccountEntriesAfterParams{
+					AccountID: account.ID,
+					FromTime:  from,
+					ToTime:    to,
+					Cursor:    42,
+					Limit:     6,
+				}
+				store.EXPECT().ListAccountEntriesAfter(gomock.Any(), arg).Times(1).Return(nil, nil)
+				store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			"BadRequest invalid cursor",
+			gin.H{"cursor": "not-a-cursor", "page_size": 5},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				store.EXPECT().ListAccountEntriesAfter(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			"Unauthorized no authorization",
+			gin.H{"page_id": 1, "page_size": 5},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			"BadRequest end time before start time",
+			gin.H{
+				"from":      to.Format(time.RFC3339),
+				"to":        from.Format(time.RFC3339),
+				"page_id":   1,
+				"page_size": 5,
+			},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			"BadRequest invalid time format",
+			gin.H{"from": "yesterday", "page_id": 1, "page_size": 5},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			"NotFound account not found",
+			gin.H{"page_id": 1, "page_size": 5},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			"Forbidden account not owned by user",
+			gin.H{"page_id": 1, "page_size": 5},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			"InternalError",
+			gin.H{"page_id": 1, "page_size": 5},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			q := url.Values{}
+			for k, v := range tc.queryParam {
+				q.Add(k, fmt.Sprint(v))
+			}
+			url := fmt.Sprintf("/accounts/%d/entries?%s", account.ID, q.Encode())
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			assert.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -1,9 +1,212 @@
 package api
 
 import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/lib/pq"
+	db "github.com/peienxie/go-bank/db/sqlc"
+)
+
+// Stable error codes returned to the client for errors it may handle
+const (
+	errCodeValidationFailed          = "validation_failed"
+	errCodeInvalidRequest            = "invalid_request"
+	errCodeInvalidCursor             = "invalid_cursor"
+	errCodeUnauthorized              = "unauthorized"
+	errCodeForbidden                 = "forbidden"
+	errCodeNotFound                  = "not_found"
+	errCodeAccountNotFound           = "account_not_found"
+	errCodeTransferNotFound          = "transfer_not_found"
+	errCodeSessionNotFound           = "session_not_found"
+	errCodeHoldNotFound              = "hold_not_found"
+	errCodeScheduledTransferNotFound = "scheduled_transfer_not_found"
+	errCodeAlreadyExists             = "already_exists"
+	errCodeInvalidReference          = "invalid_reference"
+	errCodeCurrencyMismatch          = "currency_mismatch"
+	errCodeInsufficientFunds         = "insufficient_funds"
+	errCodeIdempotencyKeyReused      = "idempotency_key_reused"
+	errCodeExchangeRateUnavailable   = "exchange_rate_unavailable"
+	errCodeConvertedAmountTooSmall   = "converted_amount_too_small"
+	errCodeTransferAlreadyReversed   = "transfer_already_reversed"
+	errCodeInvalidReversalAmount     = "invalid_reversal_amount"
+	errCodeInvalidTransferStatus     = "invalid_transfer_status"
+	errCodeHoldNotActive             = "hold_not_active"
+	errCodeHoldExpired               = "hold_expired"
+	errCodeInvalidCaptureAmount      = "invalid_capture_amount"
+	errCodeInternal                  = "internal_error"
 )
 
-func errorResponse(err error) gin.H {
-	return gin.H{"error": err.Error()}
+// Error is the error response returned to the client. Code is stable and
+// machine-readable, Message is meant for humans and may change over time.
+type Error struct {
+	Status  int          `json:"-"`
+	Code    string       `json:"code"`
+	Message string       `json:"error"`
+	Details []FieldError `json:"details,omitempty"`
+
+	// cause is the underlying error, it's logged but never sent to the client
+	cause error
+}
+
+// FieldError describes why a field of the request failed the validation
+type FieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+func newError(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	if e.cause == nil || e.cause.Error() == e.Message {
+		return e.Message
+	}
+	return e.Message + ": " + e.cause.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// notFoundError returns the error of a missing resource
+func notFoundError(code, resource string) *Error {
+	return newError(http.StatusNotFound, code, resource+" not found")
+}
+
+// forbiddenError returns the error of a resource not belonging to the authenticated user
+func forbiddenError(message string) *Error {
+	return newError(http.StatusForbidden, errCodeForbidden, message)
+}
+
+// unauthorizedError returns the error of a request failing the authentication
+func unauthorizedError(message string) *Error {
+	return newError(http.StatusUnauthorized, errCodeUnauthorized, message)
+}
+
+// currencyMismatchError returns the error of an account not in the expected currency
+func currencyMismatchError(id int64, expected, actual string) *Error {
+	message := fmt.Sprintf("account %d currency expect %s, but got %s", id, expected, actual)
+	return newError(http.StatusBadRequest, errCodeCurrencyMismatch, message)
+}
+
+// validationError returns the error of a field failing a validation
+// which can't be expressed with the binding tags of the request
+func validationError(field, reason string) *Error {
+	apiErr := newError(http.StatusBadRequest, errCodeValidationFailed, "request validation failed")
+	apiErr.Details = []FieldError{{Field: field, Reason: reason}}
+	return apiErr
+}
+
+// bindingError converts the error of binding the request into an Error
+// describing which fields are invalid without exposing the Go types
+func bindingError(err error) *Error {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		details := make([]FieldError, len(validationErrs))
+		for i, fe := range validationErrs {
+			details[i] = FieldError{Field: fe.Field(), Reason: fe.Tag()}
+		}
+		apiErr := newError(http.StatusBadRequest, errCodeValidationFailed, "request validation failed")
+		apiErr.Details = details
+		apiErr.cause = err
+		return apiErr
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		apiErr := newError(http.StatusBadRequest, errCodeValidationFailed, "request validation failed")
+		apiErr.Details = []FieldError{{Field: typeErr.Field, Reason: "type"}}
+		apiErr.cause = err
+		return apiErr
+	}
+
+	message := "invalid request parameters"
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		message = "request body is not valid JSON"
+	}
+	apiErr := newError(http.StatusBadRequest, errCodeInvalidRequest, message)
+	apiErr.cause = err
+	return apiErr
+}
+
+// domainErrors maps the errors of the store to the errors returned to the client,
+// their messages are safe to return as is
+var domainErrors = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{db.ErrInsufficientFunds, http.StatusUnprocessableEntity, errCodeInsufficientFunds},
+	{db.ErrIdempotencyKeyReused, http.StatusConflict, errCodeIdempotencyKeyReused},
+	{db.ErrConvertedAmountTooSmall, http.StatusUnprocessableEntity, errCodeConvertedAmountTooSmall},
+	{db.ErrTransferAlreadyReversed, http.StatusConflict, errCodeTransferAlreadyReversed},
+	{db.ErrInvalidReversalAmount, http.StatusUnprocessableEntity, errCodeInvalidReversalAmount},
+	{db.ErrInvalidTransferTransition, http.StatusConflict, errCodeInvalidTransferStatus},
+	{db.ErrHoldNotActive, http.StatusConflict, errCodeHoldNotActive},
+	{db.ErrHoldExpired, http.StatusConflict, errCodeHoldExpired},
+	{db.ErrInvalidCaptureAmount, http.StatusUnprocessableEntity, errCodeInvalidCaptureAmount},
+}
+
+// toError converts any error returned by the handlers into an Error,
+// errors it doesn't know about are returned as internal errors
+// so the driver messages never reach the client
+func toError(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	for _, domainErr := range domainErrors {
+		if errors.Is(err, domainErr.err) {
+			apiErr = newError(domainErr.status, domainErr.code, err.Error())
+			apiErr.cause = err
+			return apiErr
+		}
+	}
+
+	var pqErr *pq.Error
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		apiErr = notFoundError(errCodeNotFound, "resource")
+	case errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation":
+		apiErr = newError(http.StatusConflict, errCodeAlreadyExists, "resource already exists")
+	case errors.As(err, &pqErr) && pqErr.Code.Name() == "foreign_key_violation":
+		apiErr = newError(http.StatusUnprocessableEntity, errCodeInvalidReference, "referenced resource does not exist")
+	default:
+		apiErr = newError(http.StatusInternalServerError, errCodeInternal, "internal server error")
+	}
+	apiErr.cause = err
+	return apiErr
+}
+
+// abortWithError stops the handler chain and leaves the error to errorMiddleware
+func abortWithError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// errorMiddleware creates a gin middleware that writes the last error
+// recorded by the handlers as the response, it's the only place
+// errors are turned into responses apart from recovered panics
+func errorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		// the cause is logged by loggerMiddleware along with the other errors of the request
+		apiErr := toError(c.Errors.Last().Err)
+		c.JSON(apiErr.Status, apiErr)
+	}
 }
@@ -0,0 +1,194 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	db "github.com/peienxie/go-bank/db/sqlc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToError(t *testing.T) {
+	testCases := []struct {
+		name    string
+		err     error
+		status  int
+		code    string
+		message string
+	}{
+		{
+			"APIError",
+			notFoundError(errCodeAccountNotFound, "account"),
+			http.StatusNotFound,
+			errCodeAccountNotFound,
+			"account not found",
+		},
+		{
+			"DomainError",
+			fmt.Errorf("withdraw: %w", db.ErrInsufficientFunds),
+			http.StatusUnprocessableEntity,
+			errCodeInsufficientFunds,
+			"withdraw: insufficient funds",
+		},
+		{
+			"NoRows",
+			sql.ErrNoRows,
+			http.StatusNotFound,
+			errCodeNotFound,
+			"resource not found",
+		},
+		{
+			"UniqueViolation",
+			&pq.Error{Code: "23505", Message: `duplicate key value violates unique constraint "users_pkey"`},
+			http.StatusConflict,
+			errCodeAlreadyExists,
+			"resource already exists",
+		},
+		{
+			"ForeignKeyViolation",
+			&pq.Error{Code: "23503", Message: `insert or update on table "accounts" violates foreign key constraint`},
+			http.StatusUnprocessableEntity,
+			errCodeInvalidReference,
+			"referenced resource does not exist",
+		},
+		{
+			"InternalError",
+			sql.ErrConnDone,
+			http.StatusInternalServerError,
+			errCodeInternal,
+			"internal server error",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			apiErr := toError(tc.err)
+			assert.Equal(t, tc.status, apiErr.Status)
+			assert.Equal(t, tc.code, apiErr.Code)
+			assert.Equal(t, tc.message, apiErr.Message)
+			assert.ErrorIs(t, apiErr, tc.err)
+		})
+	}
+}
+
+func TestErrorMiddleware(t *testing.T) {
+	testCases := []struct {
+		name          string
+		handler       gin.HandlerFunc
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			"DriverErrorHidden",
+			func(c *gin.Context) {
+				abortWithError(c, &pq.Error{Code: "08006", Message: "connection failure"})
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+				checkErrorCode(t, recorder.Body, errCodeInternal)
+				assert.NotContains(t, recorder.Body.String(), "connection failure")
+			},
+		},
+		{
+			"LastError",
+			func(c *gin.Context) {
+				_ = c.Error(sql.ErrConnDone)
+				abortWithError(c, forbiddenError("account doesn't belong to the authenticated user"))
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusForbidden, recorder.Code)
+				checkErrorCode(t, recorder.Body, errCodeForbidden)
+			},
+		},
+		{
+			"ResponseWritten",
+			func(c *gin.Context) {
+				_ = c.Error(sql.ErrConnDone)
+				c.JSON(http.StatusOK, gin.H{})
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, nil)
+
+			path := "/error"
+			server.router.GET(path, tc.handler)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, path, nil)
+			assert.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestBindingError(t *testing.T) {
+	testCases := []struct {
+		name    string
+		body    string
+		code    string
+		details []FieldError
+	}{
+		{
+			"ValidationFailed",
+			`{"username": "invalid user", "password": "secret", "email": "invalid"}`,
+			errCodeValidationFailed,
+			[]FieldError{
+				{Field: "username", Reason: "alphanum"},
+				{Field: "full_name", Reason: "required"},
+				{Field: "email", Reason: "email"},
+			},
+		},
+		{
+			"InvalidType",
+			`{"username": 1}`,
+			errCodeValidationFailed,
+			[]FieldError{
+				{Field: "username", Reason: "type"},
+			},
+		},
+		{
+			"InvalidJSON",
+			`{"username":`,
+			errCodeInvalidRequest,
+			nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, nil)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPost, "/users", strings.NewReader(tc.body))
+			assert.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			requireBodyMatchError(t, recorder.Body, tc.code, tc.details)
+		})
+	}
+}
+
+func requireBodyMatchError(t *testing.T, body *bytes.Buffer, code string, details []FieldError) {
+	var gotErr Error
+	err := json.Unmarshal(body.Bytes(), &gotErr)
+	assert.NoError(t, err)
+	assert.Equal(t, code, gotErr.Code)
+	assert.NotEmpty(t, gotErr.Message)
+	assert.Equal(t, details, gotErr.Details)
+}
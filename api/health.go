@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/peienxie/go-bank/db/schema"
+	"github.com/peienxie/go-bank/logging"
+	"go.uber.org/zap"
+)
+
+// readinessTimeout bounds each dependency check of the readiness probe
+const readinessTimeout = 2 * time.Second
+
+const (
+	healthStatusOK   = "ok"
+	healthStatusFail = "fail"
+)
+
+func (s *Server) initHealthRoutes() {
+	s.router.GET("/healthz", s.healthz)
+	s.router.GET("/readyz", s.readyz)
+}
+
+// healthz reports the process is alive, it doesn't check any dependency
+func (s *Server) healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": healthStatusOK})
+}
+
+// dependencyCheck is the outcome of a dependency check, Error is a generic reason
+// since the probe is unauthenticated, the underlying error is only logged
+type dependencyCheck struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type readinessResponse struct {
+	Status       string                     `json:"status"`
+	Dependencies map[string]dependencyCheck `json:"dependencies"`
+}
+
+// readyz reports whether the server can serve requests, which needs the db
+// to be reachable and migrated to the schema version the server is built against
+func (s *Server) readyz(c *gin.Context) {
+	rsp := readinessResponse{
+		Status: healthStatusOK,
+		Dependencies: map[string]dependencyCheck{
+			"database":   checkDependency(c, "database", "database unavailable", s.store.Ping),
+			"migrations": checkDependency(c, "migrations", "database schema is not up to date", s.checkSchemaVersion),
+		},
+	}
+
+	status := http.StatusOK
+	for _, check := range rsp.Dependencies {
+		if check.Status != healthStatusOK {
+			rsp.Status = healthStatusFail
+			status = http.StatusServiceUnavailable
+		}
+	}
+	c.JSON(status, rsp)
+}
+
+// checkSchemaVersion verifies the migrations applied to the db are at the latest embedded version
+func (s *Server) checkSchemaVersion(ctx context.Context) error {
+	expected, err := schema.LatestVersion()
+	if err != nil {
+		return err
+	}
+
+	version, err := s.store.GetSchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if version.Dirty {
+		return fmt.Errorf("migration %d is dirty", version.Version)
+	}
+	if version.Version != expected {
+		return fmt.Errorf("schema version is %d, expected %d", version.Version, expected)
+	}
+	return nil
+}
+
+// checkDependency runs the check with the readiness timeout and reports its outcome and latency,
+// a failure is reported with the reason and its error is logged
+func checkDependency(c *gin.Context, name, reason string, check func(ctx context.Context) error) dependencyCheck {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	result := dependencyCheck{
+		Status:    healthStatusOK,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		logging.FromContext(c.Request.Context()).Warn("readiness check failed", zap.String("dependency", name), zap.Error(err))
+		result.Status = healthStatusFail
+		result.Error = reason
+	}
+	return result
+}
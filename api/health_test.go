@@ -0,0 +1,124 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/peienxie/go-bank/db/mock"
+	"github.com/peienxie/go-bank/db/schema"
+	db "github.com/peienxie/go-bank/db/sqlc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthzAPI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().Ping(gomock.Any()).Times(0)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/healthz", nil)
+	assert.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestReadyzAPI(t *testing.T) {
+	latest, err := schema.LatestVersion()
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			"OK",
+			func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().GetSchemaVersion(gomock.Any()).Times(1).Return(db.SchemaVersion{Version: latest}, nil)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+				rsp := unmarshalReadiness(t, recorder)
+				assert.Equal(t, healthStatusOK, rsp.Status)
+				assert.Equal(t, healthStatusOK, rsp.Dependencies["database"].Status)
+				assert.Equal(t, healthStatusOK, rsp.Dependencies["migrations"].Status)
+			},
+		},
+		{
+			"Unavailable database unreachable",
+			func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(sql.ErrConnDone)
+				store.EXPECT().GetSchemaVersion(gomock.Any()).Times(1).Return(db.SchemaVersion{}, sql.ErrConnDone)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+				rsp := unmarshalReadiness(t, recorder)
+				assert.Equal(t, healthStatusFail, rsp.Status)
+				assert.Equal(t, healthStatusFail, rsp.Dependencies["database"].Status)
+				// the driver error isn't exposed by the unauthenticated probe
+				assert.Equal(t, "database unavailable", rsp.Dependencies["database"].Error)
+			},
+		},
+		{
+			"Unavailable schema outdated",
+			func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().GetSchemaVersion(gomock.Any()).Times(1).Return(db.SchemaVersion{Version: latest - 1}, nil)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+				rsp := unmarshalReadiness(t, recorder)
+				assert.Equal(t, healthStatusOK, rsp.Dependencies["database"].Status)
+				assert.Equal(t, healthStatusFail, rsp.Dependencies["migrations"].Status)
+				assert.Equal(t, "database schema is not up to date", rsp.Dependencies["migrations"].Error)
+			},
+		},
+		{
+			"Unavailable schema dirty",
+			func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().GetSchemaVersion(gomock.Any()).Times(1).Return(db.SchemaVersion{Version: latest, Dirty: true}, nil)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+				rsp := unmarshalReadiness(t, recorder)
+				assert.Equal(t, healthStatusFail, rsp.Dependencies["migrations"].Status)
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/readyz", nil)
+			assert.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func unmarshalReadiness(t *testing.T, recorder *httptest.ResponseRecorder) readinessResponse {
+	var rsp readinessResponse
+	err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+	assert.NoError(t, err)
+	return rsp
+}
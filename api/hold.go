@@ -0,0 +1,252 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/peienxie/go-bank/db/sqlc"
+)
+
+func (s *Server) initHoldRoutes() {
+	authRoutes := s.router.Group("/").Use(authMiddleware(s.tokenMaker))
+	authRoutes.POST("/holds", s.createHold)
+	authRoutes.GET("/holds/:id", s.getHold)
+	authRoutes.POST("/holds/:id/capture", s.captureHold)
+	authRoutes.POST("/holds/:id/release", s.releaseHold)
+}
+
+// holdResponse is a hold with its amount rendered in the currency of its accounts,
+// TransferID is only present once the hold is captured
+type holdResponse struct {
+	ID              int64
+	AccountID       int64
+	ToAccountID     int64
+	Amount          int64
+	Status          db.HoldStatus
+	TransferID      *int64 `json:",omitempty"`
+	ExpiresAt       time.Time
+	CreatedAt       time.Time
+	FormattedAmount string
+}
+
+// newHoldResponse renders the hold, both accounts of a hold are in the same currency
+func (s *Server) newHoldResponse(hold db.Hold, currency string) holdResponse {
+	resp := holdResponse{
+		ID:              hold.ID,
+		AccountID:       hold.AccountID,
+		ToAccountID:     hold.ToAccountID,
+		Amount:          hold.Amount,
+		Status:          hold.Status,
+		ExpiresAt:       hold.ExpiresAt,
+		CreatedAt:       hold.CreatedAt,
+		FormattedAmount: s.formatAmount(currency, hold.Amount),
+	}
+	if hold.TransferID.Valid {
+		resp.TransferID = &hold.TransferID.Int64
+	}
+	return resp
+}
+
+type createHoldRequest struct {
+	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
+	Amount        int64  `json:"amount" binding:"required,gt=0"`
+	Currency      string `json:"currency" binding:"required,currency"`
+}
+
+// createHold reserves money of the user's account for a later transfer to the to account,
+// the hold expires after the configured hold duration
+func (s *Server) createHold(c *gin.Context) {
+	var req createHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	fromAccount, ok := s.validAccount(c, req.FromAccountID, req.Currency)
+	if !ok {
+		return
+	}
+
+	payload := authPayload(c)
+	if fromAccount.Username != payload.Username {
+		abortWithError(c, forbiddenError("from account doesn't belong to the authenticated user"))
+		return
+	}
+
+	if _, ok := s.validAccount(c, req.ToAccountID, req.Currency); !ok {
+		return
+	}
+
+	hold, err := s.store.PlaceHoldTx(c.Request.Context(), db.PlaceHoldTxParams{
+		AccountID:   req.FromAccountID,
+		ToAccountID: req.ToAccountID,
+		Amount:      req.Amount,
+		ExpiresAt:   time.Now().Add(s.config.HoldDuration),
+	})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, s.newHoldResponse(hold, fromAccount.Currency))
+}
+
+type getHoldRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// getHold returns the hold if the user owns the account of either side of it
+func (s *Server) getHold(c *gin.Context) {
+	var req getHoldRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	hold, ok := s.existingHold(c, req.ID)
+	if !ok {
+		return
+	}
+
+	// the from account is loaded anyway for the currency of the hold
+	fromAccount, err := s.store.GetAccount(c.Request.Context(), hold.AccountID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	username := authPayload(c).Username
+	owned := fromAccount.Username == username
+	if !owned {
+		owned, err = s.ownsAnyAccount(c, username, hold.ToAccountID)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+	}
+	if !owned {
+		abortWithError(c, forbiddenError("hold doesn't belong to the authenticated user"))
+		return
+	}
+
+	c.JSON(http.StatusOK, s.newHoldResponse(hold, fromAccount.Currency))
+}
+
+// captureHoldResponse is the transfer the hold is captured into along with the captured hold
+type captureHoldResponse struct {
+	transferTxResponse
+	Hold holdResponse `json:"hold"`
+}
+
+type captureHoldRequest struct {
+	// Amount is optional, the whole hold is captured if it's not provided
+	Amount int64 `json:"amount" binding:"omitempty,gt=0"`
+}
+
+// captureHold turns the hold into a transfer, it's requested by the owner of the receiving account
+func (s *Server) captureHold(c *gin.Context) {
+	var uri getHoldRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	var req captureHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	hold, _, ok := s.receivedHold(c, uri.ID)
+	if !ok {
+		return
+	}
+
+	result, err := s.store.CaptureHoldTx(c.Request.Context(), db.CaptureHoldTxParams{
+		HoldID: hold.ID,
+		Amount: req.Amount,
+	})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, captureHoldResponse{
+		transferTxResponse: s.newTransferTxResponse(result.TransferTxResult),
+		Hold:               s.newHoldResponse(result.Hold, result.FromAccount.Currency),
+	})
+}
+
+// releaseHold gives the held money back to the sender, it's requested by the owner of the receiving account
+func (s *Server) releaseHold(c *gin.Context) {
+	var req getHoldRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	hold, toAccount, ok := s.receivedHold(c, req.ID)
+	if !ok {
+		return
+	}
+
+	hold, err := s.store.ReleaseHoldTx(c.Request.Context(), hold.ID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, s.newHoldResponse(hold, toAccount.Currency))
+}
+
+// existingHold checks the hold exists
+func (s *Server) existingHold(c *gin.Context, id int64) (db.Hold, bool) {
+	hold, err := s.store.GetHold(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = notFoundError(errCodeHoldNotFound, "hold")
+		}
+		abortWithError(c, err)
+		return hold, false
+	}
+	return hold, true
+}
+
+// receivedHold checks the hold exists and its receiving account belongs to the authenticated user
+func (s *Server) receivedHold(c *gin.Context, id int64) (db.Hold, db.Account, bool) {
+	hold, ok := s.existingHold(c, id)
+	if !ok {
+		return hold, db.Account{}, false
+	}
+
+	toAccount, err := s.store.GetAccount(c.Request.Context(), hold.ToAccountID)
+	if err != nil {
+		abortWithError(c, err)
+		return hold, toAccount, false
+	}
+
+	if toAccount.Username != authPayload(c).Username {
+		abortWithError(c, forbiddenError("to account doesn't belong to the authenticated user"))
+		return hold, toAccount, false
+	}
+	return hold, toAccount, true
+}
+
+// ownsAnyAccount reports whether any of the accounts belongs to the user
+func (s *Server) ownsAnyAccount(c *gin.Context, username string, ids ...int64) (bool, error) {
+	for _, id := range ids {
+		account, err := s.store.GetAccount(c.Request.Context(), id)
+		if err != nil {
+			return false, err
+		}
+		if account.Username == username {
+			return true, nil
+		}
+	}
+	return false, nil
+}
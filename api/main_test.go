@@ -3,8 +3,15 @@ package api
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/peienxie/go-bank/config"
+	"github.com/peienxie/go-bank/currency"
+	db "github.com/peienxie/go-bank/db/sqlc"
+	"github.com/peienxie/go-bank/fx"
+	"github.com/peienxie/go-bank/token"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestMain(m *testing.M) {
@@ -12,3 +19,25 @@ func TestMain(m *testing.M) {
 
 	os.Exit(m.Run())
 }
+
+// newTestServer creates a server with a random token key for testing
+func newTestServer(t *testing.T, store db.Store) *Server {
+	config := config.Config{
+		TokenType:            token.TypePaseto,
+		TokenSymmetricKey:    randomString(32),
+		AccessTokenDuration:  time.Minute,
+		RefreshTokenDuration: time.Hour,
+		HoldDuration:         time.Hour,
+	}
+
+	tokenMaker, err := token.NewMaker(config.TokenType, config.TokenSymmetricKey)
+	assert.NoError(t, err)
+
+	currencies := currency.NewRegistry(
+		currency.Currency{Code: "USD", Exponent: 2, Symbol: "$"},
+		currency.Currency{Code: "TWD", Exponent: 2, Symbol: "NT$"},
+		currency.Currency{Code: "EUR", Exponent: 2, Symbol: "€"},
+	)
+
+	return NewServer(config, store, tokenMaker, currencies, fx.NewStoreProvider(store))
+}
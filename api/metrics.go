@@ -0,0 +1,48 @@
+package api
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	httpRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gobank_http_requests_total",
+		Help: "Number of HTTP requests by route and status.",
+	}, []string{"method", "route", "status"})
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gobank_http_request_duration_seconds",
+		Help:    "Latency of HTTP requests in seconds by route and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+)
+
+// unmatchedRoute labels the requests not matching any route, so unknown paths don't create new series
+const unmatchedRoute = "unmatched"
+
+func (s *Server) initMetricsRoutes() {
+	s.router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+}
+
+// metricsMiddleware creates a gin middleware that records the count and latency of requests
+// by the route pattern instead of the path, e.g. /accounts/:id
+func metricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		status := strconv.Itoa(c.Writer.Status())
+
+		httpRequestsTotal.WithLabelValues(c.Request.Method, route, status).Inc()
+		httpRequestDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/peienxie/go-bank/db/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsAPI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+	store.EXPECT().GetAccountHeldAmount(gomock.Any(), account.ID).AnyTimes().Return(int64(0), nil)
+
+	server := newTestServer(t, store)
+
+	// one request of a route and one of an unknown path
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/accounts/%d", account.ID), nil)
+	assert.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, "/unknown/path", nil)
+	assert.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, "/metrics", nil)
+	assert.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	body := recorder.Body.String()
+	assert.Contains(t, body, `gobank_http_requests_total{method="GET",route="/accounts/:id",status="200"}`)
+	assert.Contains(t, body, `gobank_http_request_duration_seconds_count{method="GET",route="/accounts/:id",status="200"}`)
+	assert.Contains(t, body, `gobank_http_requests_total{method="GET",route="unmatched",status="404"}`)
+	assert.NotContains(t, body, "/unknown/path")
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	db "github.com/peienxie/go-bank/db/sqlc"
+	"github.com/peienxie/go-bank/logging"
+	"github.com/peienxie/go-bank/token"
+	"go.uber.org/zap"
+)
+
+const (
+	authorizationHeaderKey  = "authorization"
+	authorizationTypeBearer = "bearer"
+	authorizationPayloadKey = "authorization_payload"
+)
+
+const (
+	requestIDHeaderKey = "X-Request-ID"
+	// maxRequestIDLength bounds the request IDs accepted from clients
+	maxRequestIDLength = 128
+)
+
+// requestIDMiddleware creates a gin middleware that assigns the request ID,
+// the ID set by the client or a proxy is kept so the request can be traced across services.
+// The request ID is returned in the response and added to the logger of the request context
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(requestIDHeaderKey)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+		c.Header(requestIDHeaderKey, requestID)
+
+		logger := logging.FromContext(c.Request.Context()).With(zap.String("request_id", requestID))
+		c.Request = c.Request.WithContext(logging.NewContext(c.Request.Context(), logger))
+		c.Next()
+	}
+}
+
+// validRequestID reports whether the request ID is safe to log and echo back
+func validRequestID(requestID string) bool {
+	if len(requestID) == 0 || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range requestID {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// loggerMiddleware creates a gin middleware that logs every request once it's served,
+// server errors are logged at error level and client errors at warn level
+func loggerMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		fields := []zap.Field{
+			zap.String("method", c.Request.Method),
+			zap.String("path", c.Request.URL.Path),
+			zap.String("route", c.FullPath()),
+			zap.Int("status", status),
+			zap.Duration("latency", time.Since(start)),
+			zap.String("client_ip", c.ClientIP()),
+		}
+		if len(c.Errors) > 0 {
+			fields = append(fields, zap.String("errors", c.Errors.String()))
+		}
+
+		logger := logging.FromContext(c.Request.Context())
+		switch {
+		case status >= http.StatusInternalServerError:
+			logger.Error("request served", fields...)
+		case status >= http.StatusBadRequest:
+			logger.Warn("request served", fields...)
+		default:
+			logger.Info("request served", fields...)
+		}
+	}
+}
+
+// recoveryMiddleware creates a gin middleware that recovers from panics in the handlers,
+// logs the panic and responds with an internal server error
+func recoveryMiddleware() gin.HandlerFunc {
+	// no writer for gin's own unstructured panic log
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, recovered interface{}) {
+		logging.FromContext(c.Request.Context()).Error("panic recovered", zap.Any("panic", recovered), zap.Stack("stack"))
+
+		// errorMiddleware is unwound by the panic, so the error is written here
+		apiErr := toError(fmt.Errorf("panic: %v", recovered))
+		_ = c.Error(apiErr)
+		c.AbortWithStatusJSON(apiErr.Status, apiErr)
+	})
+}
+
+// authMiddleware creates a gin middleware that verifies the bearer token
+// and stores its payload in the context for the following handlers
+func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authorizationHeader := c.GetHeader(authorizationHeaderKey)
+		if len(authorizationHeader) == 0 {
+			abortWithError(c, unauthorizedError("authorization header is not provided"))
+			return
+		}
+
+		fields := strings.Fields(authorizationHeader)
+		if len(fields) != 2 {
+			abortWithError(c, unauthorizedError("invalid authorization header format"))
+			return
+		}
+
+		authorizationType := strings.ToLower(fields[0])
+		if authorizationType != authorizationTypeBearer {
+			abortWithError(c, unauthorizedError(fmt.Sprintf("unsupported authorization type %s", authorizationType)))
+			return
+		}
+
+		payload, err := tokenMaker.VerifyToken(fields[1], token.AudienceAccess)
+		if err != nil {
+			abortWithError(c, unauthorizedError(err.Error()))
+			return
+		}
+
+		c.Set(authorizationPayloadKey, payload)
+		c.Next()
+	}
+}
+
+// operatorMiddleware creates a gin middleware that only lets operators through,
+// it must follow authMiddleware. The role is read from db on every request
+// so a revoked operator loses the access immediately
+func operatorMiddleware(store db.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := store.GetUser(c.Request.Context(), authPayload(c).Username)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				err = unauthorizedError("user of the token doesn't exist")
+			}
+			abortWithError(c, err)
+			return
+		}
+
+		if user.Role != db.UserRoleOperator {
+			abortWithError(c, forbiddenError("operator role is required"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// authPayload returns the token payload stored by authMiddleware
+func authPayload(c *gin.Context) *token.Payload {
+	return c.MustGet(authorizationPayloadKey).(*token.Payload)
+}
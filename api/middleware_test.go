@@ -0,0 +1,235 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/peienxie/go-bank/logging"
+	"github.com/peienxie/go-bank/token"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// addAuthorization sets the authorization header of the request with a new token
+func addAuthorization(
+	t *testing.T,
+	request *http.Request,
+	tokenMaker token.Maker,
+	authorizationType string,
+	username string,
+	duration time.Duration,
+) {
+	token, payload, err := tokenMaker.CreateToken(username, token.AudienceAccess, duration)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, payload)
+
+	authorizationHeader := fmt.Sprintf("%s %s", authorizationType, token)
+	request.Header.Set(authorizationHeaderKey, authorizationHeader)
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	username := randomUsername()
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			"OK",
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, username, time.Minute)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			"Unauthorized no authorization",
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			"Unauthorized unsupported authorization type",
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, "unsupported", username, time.Minute)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			"Unauthorized invalid authorization format",
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, "", username, time.Minute)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			"Unauthorized refresh token",
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				refreshToken, _, err := tokenMaker.CreateToken(username, token.AudienceRefresh, time.Minute)
+				assert.NoError(t, err)
+				request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, refreshToken))
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			"Unauthorized expired token",
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, username, -time.Minute)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, nil)
+
+			authPath := "/auth"
+			server.router.GET(
+				authPath,
+				authMiddleware(server.tokenMaker),
+				func(c *gin.Context) {
+					c.JSON(http.StatusOK, gin.H{})
+				},
+			)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, authPath, nil)
+			assert.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	testCases := []struct {
+		name          string
+		requestID     string
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			"Propagated",
+			"client-request-id",
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, "client-request-id", recorder.Header().Get(requestIDHeaderKey))
+			},
+		},
+		{
+			"Generated",
+			"",
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				_, err := uuid.Parse(recorder.Header().Get(requestIDHeaderKey))
+				assert.NoError(t, err)
+			},
+		},
+		{
+			"Replaced invalid",
+			"invalid request id",
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				_, err := uuid.Parse(recorder.Header().Get(requestIDHeaderKey))
+				assert.NoError(t, err)
+			},
+		},
+		{
+			"Replaced too long",
+			strings.Repeat("a", maxRequestIDLength+1),
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				_, err := uuid.Parse(recorder.Header().Get(requestIDHeaderKey))
+				assert.NoError(t, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, nil)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, "/healthz", nil)
+			assert.NoError(t, err)
+			if tc.requestID != "" {
+				request.Header.Set(requestIDHeaderKey, tc.requestID)
+			}
+
+			server.router.ServeHTTP(recorder, request)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestLoggerMiddleware(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	defer zap.ReplaceGlobals(zap.New(core))()
+
+	server := newTestServer(t, nil)
+
+	// the request ID is carried by the context passed to the handler
+	server.router.GET("/log", func(c *gin.Context) {
+		logging.FromContext(c.Request.Context()).Info("handled")
+		c.JSON(http.StatusTeapot, gin.H{})
+	})
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/log", nil)
+	assert.NoError(t, err)
+	request.Header.Set(requestIDHeaderKey, "client-request-id")
+
+	server.router.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusTeapot, recorder.Code)
+
+	entries := logs.All()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "handled", entries[0].Message)
+	assert.Equal(t, "client-request-id", entries[0].ContextMap()["request_id"])
+
+	assert.Equal(t, "request served", entries[1].Message)
+	assert.Equal(t, zap.WarnLevel, entries[1].Level)
+	fields := entries[1].ContextMap()
+	assert.Equal(t, "client-request-id", fields["request_id"])
+	assert.Equal(t, "/log", fields["route"])
+	assert.Equal(t, int64(http.StatusTeapot), fields["status"])
+}
+
+func TestRecoveryMiddleware(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	defer zap.ReplaceGlobals(zap.New(core))()
+
+	server := newTestServer(t, nil)
+	server.router.GET("/panic", func(c *gin.Context) {
+		panic("handler panic")
+	})
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/panic", nil)
+	assert.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	checkErrorCode(t, recorder.Body, errCodeInternal)
+	assert.NotContains(t, recorder.Body.String(), "handler panic")
+	assert.Equal(t, 1, logs.FilterMessage("panic recovered").Len())
+	assert.Equal(t, zap.ErrorLevel, logs.FilterMessage("request served").All()[0].Level)
+}
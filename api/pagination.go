@@ -0,0 +1,55 @@
+package api
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strconv"
+)
+
+var errInvalidCursor = newError(http.StatusBadRequest, errCodeInvalidCursor, "invalid cursor")
+
+// pageRequest holds the pagination parameters shared by the list endpoints.
+// page_id selects the legacy offset pagination, otherwise the keyset
+// pagination starts after the row referenced by cursor.
+type pageRequest struct {
+	PageID   *int32 `form:"page_id" binding:"omitempty,min=1,excluded_with=Cursor"`
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=10"`
+	Cursor   string `form:"cursor"`
+}
+
+// useOffset reports whether the request asks for offset pagination
+func (r pageRequest) useOffset() bool {
+	return r.PageID != nil
+}
+
+// offset returns the number of rows to skip for offset pagination
+func (r pageRequest) offset() int32 {
+	return (*r.PageID - 1) * r.PageSize
+}
+
+// pageResponse is the response body of a keyset paginated list
+type pageResponse struct {
+	Items      interface{} `json:"items"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// encodeCursor returns an opaque cursor pointing at the row id
+func encodeCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+// decodeCursor returns the row id of the cursor, an empty cursor starts from the beginning
+func decodeCursor(cursor string) (int64, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, errInvalidCursor
+	}
+	id, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil || id < 1 {
+		return 0, errInvalidCursor
+	}
+	return id, nil
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursor(t *testing.T) {
+	id := randomInt(1, 1000)
+
+	cursor := encodeCursor(id)
+	assert.NotEmpty(t, cursor)
+
+	got, err := decodeCursor(cursor)
+	assert.NoError(t, err)
+	assert.Equal(t, id, got)
+
+	got, err = decodeCursor("")
+	assert.NoError(t, err)
+	assert.Zero(t, got)
+}
+
+func TestDecodeInvalidCursor(t *testing.T) {
+	for _, cursor := range []string{"!!!", encodeCursor(0), "YWJj"} {
+		_, err := decodeCursor(cursor)
+		assert.ErrorIs(t, err, errInvalidCursor)
+	}
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPassword(t *testing.T) {
+	password := randomString(6)
+
+	hashedPassword1, err := hashPassword(password)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, hashedPassword1)
+
+	err = checkPassword(password, hashedPassword1)
+	assert.NoError(t, err)
+
+	wrongPassword := randomString(6)
+	err = checkPassword(wrongPassword, hashedPassword1)
+	assert.EqualError(t, err, bcrypt.ErrMismatchedHashAndPassword.Error())
+
+	// same password should be hashed with different salt
+	hashedPassword2, err := hashPassword(password)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, hashedPassword2)
+	assert.NotEqual(t, hashedPassword1, hashedPassword2)
+}
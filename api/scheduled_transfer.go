@@ -0,0 +1,342 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/peienxie/go-bank/db/sqlc"
+)
+
+func (s *Server) initScheduledTransferRoutes() {
+	authRoutes := s.router.Group("/").Use(authMiddleware(s.tokenMaker))
+	authRoutes.POST("/scheduled_transfers", s.createScheduledTransfer)
+	authRoutes.GET("/scheduled_transfers/:id", s.getScheduledTransfer)
+	authRoutes.GET("/scheduled_transfers/:id/runs", s.listScheduledTransferRuns)
+	authRoutes.GET("/scheduled_transfers", s.listScheduledTransfers)
+	authRoutes.PUT("/scheduled_transfers/:id", s.updateScheduledTransfer)
+	authRoutes.DELETE("/scheduled_transfers/:id", s.deleteScheduledTransfer)
+}
+
+// scheduledTransferResponse is a scheduled transfer with its amount rendered in its currency.
+// NextRunAt is absent once the schedule is finished and EndAt is absent when it's repeated
+// until it's deleted
+type scheduledTransferResponse struct {
+	ID              int64
+	Username        string
+	FromAccountID   int64
+	ToAccountID     int64
+	Amount          int64
+	Currency        string
+	Schedule        string
+	StartAt         time.Time
+	NextRunAt       *time.Time `json:",omitempty"`
+	EndAt           *time.Time `json:",omitempty"`
+	CreatedAt       time.Time
+	FormattedAmount string
+}
+
+func (s *Server) newScheduledTransferResponse(scheduled db.ScheduledTransfer) scheduledTransferResponse {
+	resp := scheduledTransferResponse{
+		ID:              scheduled.ID,
+		Username:        scheduled.Username,
+		FromAccountID:   scheduled.FromAccountID,
+		ToAccountID:     scheduled.ToAccountID,
+		Amount:          scheduled.Amount,
+		Currency:        scheduled.Currency,
+		Schedule:        scheduled.Schedule,
+		StartAt:         scheduled.StartAt,
+		CreatedAt:       scheduled.CreatedAt,
+		FormattedAmount: s.formatAmount(scheduled.Currency, scheduled.Amount),
+	}
+	if scheduled.NextRunAt.Valid {
+		resp.NextRunAt = &scheduled.NextRunAt.Time
+	}
+	if scheduled.EndAt.Valid {
+		resp.EndAt = &scheduled.EndAt.Time
+	}
+	return resp
+}
+
+func (s *Server) newScheduledTransferResponses(scheduledTransfers []db.ScheduledTransfer) []scheduledTransferResponse {
+	resp := make([]scheduledTransferResponse, len(scheduledTransfers))
+	for i, scheduled := range scheduledTransfers {
+		resp[i] = s.newScheduledTransferResponse(scheduled)
+	}
+	return resp
+}
+
+// scheduledTransferRunResponse is a run of a scheduled transfer. TransferID is only
+// present for a succeeded run and Error only for a failed one
+type scheduledTransferRunResponse struct {
+	ID                  int64
+	ScheduledTransferID int64
+	Status              db.ScheduledTransferRunStatus
+	TransferID          *int64 `json:",omitempty"`
+	Error               string `json:",omitempty"`
+	CreatedAt           time.Time
+}
+
+func newScheduledTransferRunResponse(run db.ScheduledTransferRun) scheduledTransferRunResponse {
+	resp := scheduledTransferRunResponse{
+		ID:                  run.ID,
+		ScheduledTransferID: run.ScheduledTransferID,
+		Status:              run.Status,
+		Error:               run.Error,
+		CreatedAt:           run.CreatedAt,
+	}
+	if run.TransferID.Valid {
+		resp.TransferID = &run.TransferID.Int64
+	}
+	return resp
+}
+
+type createScheduledTransferRequest struct {
+	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
+	Amount        int64  `json:"amount" binding:"required,gt=0"`
+	Currency      string `json:"currency" binding:"required,currency"`
+	Schedule      string `json:"schedule" binding:"required,schedule"`
+	// StartAt is the time of the first run, it runs as soon as possible if it's not provided
+	StartAt time.Time `json:"start_at"`
+	// EndAt is optional, the transfer is repeated until the scheduled transfer is deleted if it's not provided
+	EndAt time.Time `json:"end_at" binding:"omitempty,gtfield=StartAt"`
+}
+
+// createScheduledTransfer creates a standing order from the user's account, the transfers
+// are made by the scheduler in the background and only between accounts of the same currency
+func (s *Server) createScheduledTransfer(c *gin.Context) {
+	var req createScheduledTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	fromAccount, ok := s.validAccount(c, req.FromAccountID, req.Currency)
+	if !ok {
+		return
+	}
+
+	payload := authPayload(c)
+	if fromAccount.Username != payload.Username {
+		abortWithError(c, forbiddenError("from account doesn't belong to the authenticated user"))
+		return
+	}
+
+	if _, ok := s.validAccount(c, req.ToAccountID, req.Currency); !ok {
+		return
+	}
+
+	startAt := req.StartAt
+	if startAt.IsZero() {
+		startAt = time.Now()
+	}
+
+	scheduled, err := s.store.CreateScheduledTransfer(c.Request.Context(), db.CreateScheduledTransferParams{
+		Username:      payload.Username,
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		Schedule:      req.Schedule,
+		StartAt:       startAt,
+		NextRunAt:     sql.NullTime{Time: startAt, Valid: true},
+		EndAt:         nullTime(req.EndAt),
+	})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, s.newScheduledTransferResponse(scheduled))
+}
+
+type scheduledTransferURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (s *Server) getScheduledTransfer(c *gin.Context) {
+	var uri scheduledTransferURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	scheduled, ok := s.ownedScheduledTransfer(c, uri.ID)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, s.newScheduledTransferResponse(scheduled))
+}
+
+// listScheduledTransferRuns lists the runs of the scheduled transfer from the oldest,
+// a failed run carries the reason the transfer was not made
+func (s *Server) listScheduledTransferRuns(c *gin.Context) {
+	var uri scheduledTransferURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	if _, ok := s.ownedScheduledTransfer(c, uri.ID); !ok {
+		return
+	}
+
+	runs, err := s.store.ListScheduledTransferRuns(c.Request.Context(), uri.ID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	resp := make([]scheduledTransferRunResponse, len(runs))
+	for i, run := range runs {
+		resp[i] = newScheduledTransferRunResponse(run)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+type listScheduledTransfersRequest struct {
+	pageRequest
+}
+
+func (s *Server) listScheduledTransfers(c *gin.Context) {
+	var req listScheduledTransfersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	payload := authPayload(c)
+	if req.useOffset() {
+		arg := db.ListScheduledTransfersByUsernameParams{
+			Username: payload.Username,
+			Limit:    req.PageSize,
+			Offset:   req.offset(),
+		}
+		scheduledTransfers, err := s.store.ListScheduledTransfersByUsername(c.Request.Context(), arg)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, s.newScheduledTransferResponses(scheduledTransfers))
+		return
+	}
+
+	cursor, err := decodeCursor(req.Cursor)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	// fetch one extra row to find out whether there is a next page
+	arg := db.ListScheduledTransfersByUsernameAfterParams{
+		Username: payload.Username,
+		Cursor:   cursor,
+		Limit:    req.PageSize + 1,
+	}
+	scheduledTransfers, err := s.store.ListScheduledTransfersByUsernameAfter(c.Request.Context(), arg)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	resp := pageResponse{Items: s.newScheduledTransferResponses(scheduledTransfers)}
+	if len(scheduledTransfers) > int(req.PageSize) {
+		scheduledTransfers = scheduledTransfers[:req.PageSize]
+		resp.Items = s.newScheduledTransferResponses(scheduledTransfers)
+		resp.NextCursor = encodeCursor(scheduledTransfers[len(scheduledTransfers)-1].ID)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+type updateScheduledTransferRequest struct {
+	Amount   int64  `json:"amount" binding:"required,gt=0"`
+	Schedule string `json:"schedule" binding:"required,schedule"`
+	// EndAt is optional, the transfer is repeated until the scheduled transfer is deleted if it's not provided.
+	// It can't be before the next run, which is kept by the update
+	EndAt time.Time `json:"end_at"`
+}
+
+// updateScheduledTransfer replaces the amount, schedule and end of the scheduled transfer,
+// the next run is kept and a new schedule starts over from it
+func (s *Server) updateScheduledTransfer(c *gin.Context) {
+	var uri scheduledTransferURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	var req updateScheduledTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	scheduled, ok := s.ownedScheduledTransfer(c, uri.ID)
+	if !ok {
+		return
+	}
+
+	if !req.EndAt.IsZero() && scheduled.NextRunAt.Valid && req.EndAt.Before(scheduled.NextRunAt.Time) {
+		abortWithError(c, validationError("end_at", "after_next_run"))
+		return
+	}
+
+	scheduled, err := s.store.UpdateScheduledTransfer(c.Request.Context(), db.UpdateScheduledTransferParams{
+		ID:       uri.ID,
+		Amount:   req.Amount,
+		Schedule: req.Schedule,
+		EndAt:    nullTime(req.EndAt),
+	})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, s.newScheduledTransferResponse(scheduled))
+}
+
+func (s *Server) deleteScheduledTransfer(c *gin.Context) {
+	var uri scheduledTransferURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	if _, ok := s.ownedScheduledTransfer(c, uri.ID); !ok {
+		return
+	}
+
+	if err := s.store.DeleteScheduledTransfer(c.Request.Context(), uri.ID); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ownedScheduledTransfer checks the scheduled transfer exists and belongs to the authenticated user
+func (s *Server) ownedScheduledTransfer(c *gin.Context, id int64) (db.ScheduledTransfer, bool) {
+	scheduled, err := s.store.GetScheduledTransfer(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = notFoundError(errCodeScheduledTransferNotFound, "scheduled transfer")
+		}
+		abortWithError(c, err)
+		return scheduled, false
+	}
+
+	if scheduled.Username != authPayload(c).Username {
+		abortWithError(c, forbiddenError("scheduled transfer doesn't belong to the authenticated user"))
+		return scheduled, false
+	}
+	return scheduled, true
+}
+
+// nullTime returns a null time for the zero time
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
@@ -0,0 +1,656 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/peienxie/go-bank/db/mock"
+	db "github.com/peienxie/go-bank/db/sqlc"
+	"github.com/peienxie/go-bank/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateScheduledTransferAPI(t *testing.T) {
+	amount := randomMoney()
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account2.ID = account1.ID + 1
+	account1.Currency = "USD"
+	account2.Currency = "USD"
+	startAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	endAt := startAt.AddDate(1, 0, 0)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			"OK",
+			gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        "USD",
+				"schedule":        "@monthly",
+				"start_at":        startAt,
+				"end_at":          endAt,
+			},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(1).Return(account2, nil)
+				arg := db.CreateScheduledTransferParams{
+					Username:      user1.Username,
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+					Amount:        amount,
+					Currency:      "USD",
+					Schedule:      "@monthly",
+					StartAt:       startAt,
+					NextRunAt:     sql.NullTime{Time: startAt, Valid: true},
+					EndAt:         sql.NullTime{Time: endAt, Valid: true},
+				}
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, got db.CreateScheduledTransferParams) (db.ScheduledTransfer, error) {
+						assert.Equal(t, arg.Username, got.Username)
+						assert.Equal(t, arg.Amount, got.Amount)
+						assert.Equal(t, arg.Schedule, got.Schedule)
+						assert.True(t, arg.StartAt.Equal(got.StartAt))
+						assert.True(t, arg.NextRunAt.Time.Equal(got.NextRunAt.Time))
+						assert.True(t, arg.EndAt.Time.Equal(got.EndAt.Time))
+						return db.ScheduledTransfer{ID: 1}, nil
+					})
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			"OK starts now without end",
+			gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        "USD",
+				"schedule":        "@every 24h",
+			},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(1).Return(account2, nil)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, got db.CreateScheduledTransferParams) (db.ScheduledTransfer, error) {
+						assert.WithinDuration(t, time.Now(), got.NextRunAt.Time, time.Second)
+						assert.False(t, got.EndAt.Valid)
+						return db.ScheduledTransfer{ID: 1}, nil
+					})
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			"BadRequest invalid schedule",
+			gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        "USD",
+				"schedule":        "0 9 * * *",
+			},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			"BadRequest end before start",
+			gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        "USD",
+				"schedule":        "@daily",
+				"start_at":        endAt,
+				"end_at":          startAt,
+			},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			"BadRequest to account currency mismatch",
+			gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        "USD",
+				"schedule":        "@daily",
+			},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				otherCurrencyAccount := account2
+				otherCurrencyAccount.Currency = "TWD"
+				store.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(1).Return(otherCurrencyAccount, nil)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			"Forbidden from account not owned by user",
+			gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        "USD",
+				"schedule":        "@daily",
+			},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(account1, nil)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			assert.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/scheduled_transfers", bytes.NewReader(data))
+			assert.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestScheduledTransferAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	scheduled := db.ScheduledTransfer{
+		ID:            randomInt(1, 1000),
+		Username:      user.Username,
+		FromAccountID: randomInt(1, 1000),
+		ToAccountID:   randomInt(1, 1000),
+		Amount:        randomMoney(),
+		Currency:      "USD",
+		Schedule:      "@daily",
+		StartAt:       time.Date(2022, time.February, 1, 9, 0, 0, 0, time.UTC),
+		NextRunAt:     sql.NullTime{Time: time.Date(2022, time.March, 1, 9, 0, 0, 0, time.UTC), Valid: true},
+	}
+	updateBody := gin.H{"amount": 10, "schedule": "@weekly"}
+
+	testCases := []struct {
+		name          string
+		method        string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			"OK get",
+			http.MethodGet,
+			nil,
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), scheduled.ID).Times(1).Return(scheduled, nil)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+
+				var got scheduledTransferResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				assert.NoError(t, err)
+				assert.Equal(t, newTestServer(t, nil).newScheduledTransferResponse(scheduled), got)
+
+				// the nullable times are plain timestamps, absent when they're null
+				var fields map[string]interface{}
+				err = json.Unmarshal(recorder.Body.Bytes(), &fields)
+				assert.NoError(t, err)
+				assert.Equal(t, scheduled.NextRunAt.Time.Format(time.RFC3339), fields["NextRunAt"])
+				assert.NotContains(t, fields, "EndAt")
+			},
+		},
+		{
+			"Forbidden get",
+			http.MethodGet,
+			nil,
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), scheduled.ID).Times(1).Return(scheduled, nil)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			"NotFound get",
+			http.MethodGet,
+			nil,
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), scheduled.ID).Times(1).Return(db.ScheduledTransfer{}, sql.ErrNoRows)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			"OK update",
+			http.MethodPut,
+			updateBody,
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), scheduled.ID).Times(1).Return(scheduled, nil)
+				updated := scheduled
+				updated.Amount = 10
+				updated.Schedule = "@weekly"
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), db.UpdateScheduledTransferParams{
+					ID:       scheduled.ID,
+					Amount:   10,
+					Schedule: "@weekly",
+				}).Times(1).Return(updated, nil)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+
+				var got scheduledTransferResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				assert.NoError(t, err)
+				assert.Equal(t, int64(10), got.Amount)
+				assert.Equal(t, "$0.10", got.FormattedAmount)
+				assert.Equal(t, "@weekly", got.Schedule)
+			},
+		},
+		{
+			"BadRequest update end before next run",
+			http.MethodPut,
+			gin.H{"amount": 10, "schedule": "@weekly", "end_at": scheduled.NextRunAt.Time.Add(-time.Hour)},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), scheduled.ID).Times(1).Return(scheduled, nil)
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errCodeValidationFailed, []FieldError{{Field: "end_at", Reason: "after_next_run"}})
+			},
+		},
+		{
+			"BadRequest update invalid schedule",
+			http.MethodPut,
+			gin.H{"amount": 10, "schedule": "@every 1s"},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			"Forbidden update",
+			http.MethodPut,
+			updateBody,
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), scheduled.ID).Times(1).Return(scheduled, nil)
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			"NoContent delete",
+			http.MethodDelete,
+			nil,
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), scheduled.ID).Times(1).Return(scheduled, nil)
+				store.EXPECT().DeleteScheduledTransfer(gomock.Any(), scheduled.ID).Times(1).Return(nil)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			"Forbidden delete",
+			http.MethodDelete,
+			nil,
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), scheduled.ID).Times(1).Return(scheduled, nil)
+				store.EXPECT().DeleteScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			"InternalError delete",
+			http.MethodDelete,
+			nil,
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), scheduled.ID).Times(1).Return(scheduled, nil)
+				store.EXPECT().DeleteScheduledTransfer(gomock.Any(), scheduled.ID).Times(1).Return(sql.ErrConnDone)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			var body bytes.Buffer
+			if tc.body != nil {
+				assert.NoError(t, json.NewEncoder(&body).Encode(tc.body))
+			}
+
+			url := fmt.Sprintf("/scheduled_transfers/%d", scheduled.ID)
+			request, err := http.NewRequest(tc.method, url, &body)
+			assert.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListScheduledTransferRunsAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	scheduled := db.ScheduledTransfer{
+		ID:       randomInt(1, 1000),
+		Username: user.Username,
+	}
+	runs := []db.ScheduledTransferRun{
+		{
+			ID:                  1,
+			ScheduledTransferID: scheduled.ID,
+			Status:              db.ScheduledTransferRunStatusSucceeded,
+			TransferID:          sql.NullInt64{Int64: randomInt(1, 1000), Valid: true},
+		},
+		{
+			ID:                  2,
+			ScheduledTransferID: scheduled.ID,
+			Status:              db.ScheduledTransferRunStatusFailed,
+			Error:               db.ErrInsufficientFunds.Error(),
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			"OK",
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), scheduled.ID).Times(1).Return(scheduled, nil)
+				store.EXPECT().ListScheduledTransferRuns(gomock.Any(), scheduled.ID).Times(1).Return(runs, nil)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+
+				var got []map[string]interface{}
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				assert.NoError(t, err)
+				assert.Len(t, got, len(runs))
+				// the transfer id is a plain number, absent for a failed run
+				assert.Equal(t, float64(runs[0].TransferID.Int64), got[0]["TransferID"])
+				assert.NotContains(t, got[0], "Error")
+				assert.NotContains(t, got[1], "TransferID")
+				assert.Equal(t, runs[1].Error, got[1]["Error"])
+			},
+		},
+		{
+			"Forbidden",
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), scheduled.ID).Times(1).Return(scheduled, nil)
+				store.EXPECT().ListScheduledTransferRuns(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusForbidden, recorder.Code)
+				checkErrorCode(t, recorder.Body, errCodeForbidden)
+			},
+		},
+		{
+			"NotFound",
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), scheduled.ID).Times(1).Return(db.ScheduledTransfer{}, sql.ErrNoRows)
+				store.EXPECT().ListScheduledTransferRuns(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, recorder.Code)
+				checkErrorCode(t, recorder.Body, errCodeScheduledTransferNotFound)
+			},
+		},
+		{
+			"Unauthorized",
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListScheduledTransferRuns(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			"InternalError",
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), scheduled.ID).Times(1).Return(scheduled, nil)
+				store.EXPECT().ListScheduledTransferRuns(gomock.Any(), scheduled.ID).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/scheduled_transfers/%d/runs", scheduled.ID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			assert.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListScheduledTransfersAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	pageSize := 5
+
+	scheduledTransfers := make([]db.ScheduledTransfer, pageSize+1)
+	for i := range scheduledTransfers {
+		scheduledTransfers[i] = db.ScheduledTransfer{ID: int64(i + 1), Username: user.Username}
+	}
+
+	testCases := []struct {
+		name          string
+		query         url.Values
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			"OK offset",
+			url.Values{"page_id": {"2"}, "page_size": {"5"}},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().ListScheduledTransfersByUsername(gomock.Any(), db.ListScheduledTransfersByUsernameParams{
+					Username: user.Username,
+					Limit:    5,
+					Offset:   5,
+				}).Times(1).Return(scheduledTransfers[:1], nil)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+
+				var got []scheduledTransferResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				assert.NoError(t, err)
+				assert.Equal(t, newTestServer(t, nil).newScheduledTransferResponses(scheduledTransfers[:1]), got)
+			},
+		},
+		{
+			"OK cursor",
+			url.Values{"page_size": {"5"}},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().ListScheduledTransfersByUsernameAfter(gomock.Any(), db.ListScheduledTransfersByUsernameAfterParams{
+					Username: user.Username,
+					Limit:    6,
+				}).Times(1).Return(scheduledTransfers, nil)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+
+				var got struct {
+					Items      []scheduledTransferResponse `json:"items"`
+					NextCursor string                      `json:"next_cursor"`
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				assert.NoError(t, err)
+				assert.Equal(t, newTestServer(t, nil).newScheduledTransferResponses(scheduledTransfers[:pageSize]), got.Items)
+				assert.Equal(t, encodeCursor(int64(pageSize)), got.NextCursor)
+			},
+		},
+		{
+			"BadRequest invalid cursor",
+			url.Values{"page_size": {"5"}, "cursor": {"!"}},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().ListScheduledTransfersByUsernameAfter(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/scheduled_transfers?"+tc.query.Encode(), nil)
+			assert.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -1,31 +1,100 @@
 package api
 
 import (
+	"context"
+	"net"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/peienxie/go-bank/config"
+	"github.com/peienxie/go-bank/currency"
 	db "github.com/peienxie/go-bank/db/sqlc"
+	"github.com/peienxie/go-bank/fx"
+	"github.com/peienxie/go-bank/token"
 )
 
 // Server serves HTTP requests
 type Server struct {
-	store  db.Store
-	router *gin.Engine
+	config     config.Config
+	store      db.Store
+	tokenMaker token.Maker
+	currencies *currency.Registry
+	rates      fx.RateProvider
+	router     *gin.Engine
+	httpServer *http.Server
 }
 
 // NewServer creates a new HTTP server and setup its routing
-func NewServer(store db.Store) *Server {
+func NewServer(
+	config config.Config,
+	store db.Store,
+	tokenMaker token.Maker,
+	currencies *currency.Registry,
+	rates fx.RateProvider,
+) *Server {
 	server := &Server{
-		store:  store,
-		router: gin.Default(),
+		config:     config,
+		store:      store,
+		tokenMaker: tokenMaker,
+		currencies: currencies,
+		rates:      rates,
+		router:     gin.New(),
+	}
+	server.router.Use(
+		requestIDMiddleware(),
+		tracingMiddleware(),
+		loggerMiddleware(),
+		recoveryMiddleware(),
+		metricsMiddleware(),
+		errorMiddleware(),
+	)
+	server.httpServer = &http.Server{
+		Handler:      server.router,
+		ReadTimeout:  config.ServerReadTimeout,
+		WriteTimeout: config.ServerWriteTimeout,
+		IdleTimeout:  config.ServerIdleTimeout,
+	}
+
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterValidation("currency", validCurrency(currencies))
+		v.RegisterValidation("schedule", validSchedule)
+		v.RegisterTagNameFunc(fieldName)
 	}
 
 	// initilizes routing
+	server.initHealthRoutes()
+	server.initMetricsRoutes()
+	server.initUserRoutes()
+	server.initTokenRoutes()
 	server.initAccountRoutes()
 	server.initTransferRoutes()
+	server.initCashRoutes()
+	server.initEntryRoutes()
+	server.initHoldRoutes()
+	server.initScheduledTransferRoutes()
 
 	return server
 }
 
-// Serve runs the http server on the provided address
+// Serve runs the http server on the provided address until it's shut down,
+// it returns nil if the server is stopped by Shutdown
 func (s *Server) Serve(addr string) error {
-	return s.router.Run(addr)
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	err = s.httpServer.Serve(listener)
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+// Shutdown stops accepting new connections and waits for the in-flight requests to finish,
+// the remaining connections are closed if the context is done first
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.httpServer.Shutdown(ctx)
 }
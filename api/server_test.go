@@ -0,0 +1,42 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/peienxie/go-bank/db/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServeShutdown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newTestServer(t, mockdb.NewMockStore(ctrl))
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve("127.0.0.1:0")
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, server.Shutdown(ctx))
+
+	select {
+	case err := <-serveErr:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("server is not stopped after shutdown")
+	}
+}
+
+func TestServeInvalidAddress(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newTestServer(t, mockdb.NewMockStore(ctrl))
+	assert.Error(t, server.Serve("invalid address"))
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/peienxie/go-bank/token"
+)
+
+func (s *Server) initTokenRoutes() {
+	s.router.POST("/tokens/renew_access", s.renewAccessToken)
+}
+
+type renewAccessTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type renewAccessTokenResponse struct {
+	AccessToken          string    `json:"access_token"`
+	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+}
+
+func (s *Server) renewAccessToken(c *gin.Context) {
+	var req renewAccessTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	refreshPayload, err := s.tokenMaker.VerifyToken(req.RefreshToken, token.AudienceRefresh)
+	if err != nil {
+		abortWithError(c, unauthorizedError(err.Error()))
+		return
+	}
+
+	session, err := s.store.GetSession(c.Request.Context(), refreshPayload.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = notFoundError(errCodeSessionNotFound, "session")
+		}
+		abortWithError(c, err)
+		return
+	}
+
+	if session.IsBlocked {
+		abortWithError(c, unauthorizedError("session is blocked"))
+		return
+	}
+
+	if session.Username != refreshPayload.Username {
+		abortWithError(c, unauthorizedError("session user does not match the token"))
+		return
+	}
+
+	if session.RefreshToken != req.RefreshToken {
+		abortWithError(c, unauthorizedError("session token does not match the token"))
+		return
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		abortWithError(c, unauthorizedError("session has expired"))
+		return
+	}
+
+	accessToken, accessPayload, err := s.tokenMaker.CreateToken(refreshPayload.Username, token.AudienceAccess, s.config.AccessTokenDuration)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, renewAccessTokenResponse{
+		AccessToken:          accessToken,
+		AccessTokenExpiresAt: accessPayload.ExpiredAt,
+	})
+}
@@ -0,0 +1,233 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/peienxie/go-bank/db/mock"
+	db "github.com/peienxie/go-bank/db/sqlc"
+	"github.com/peienxie/go-bank/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenewAccessTokenAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		buildToken    func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload)
+		buildStubs    func(store *mockdb.MockStore, refreshToken string, payload *token.Payload)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			"OK",
+			func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				return createTestToken(t, tokenMaker, user.Username, token.AudienceRefresh, time.Hour)
+			},
+			func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(randomSession(payload, refreshToken), nil)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			"Unauthorized invalid refresh token",
+			func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				return "invalid", nil
+			},
+			func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			"Unauthorized access token",
+			func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				return createTestToken(t, tokenMaker, user.Username, token.AudienceAccess, time.Hour)
+			},
+			func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			"Unauthorized expired refresh token",
+			func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				return createTestToken(t, tokenMaker, user.Username, token.AudienceRefresh, -time.Minute)
+			},
+			func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			"NotFound session not found",
+			func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				return createTestToken(t, tokenMaker, user.Username, token.AudienceRefresh, time.Hour)
+			},
+			func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(db.Session{}, sql.ErrNoRows)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			"Unauthorized blocked session",
+			func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				return createTestToken(t, tokenMaker, user.Username, token.AudienceRefresh, time.Hour)
+			},
+			func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				session := randomSession(payload, refreshToken)
+				session.IsBlocked = true
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(session, nil)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			"Unauthorized session user mismatch",
+			func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				return createTestToken(t, tokenMaker, user.Username, token.AudienceRefresh, time.Hour)
+			},
+			func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				session := randomSession(payload, refreshToken)
+				session.Username = randomUsername()
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(session, nil)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			"Unauthorized session token mismatch",
+			func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				return createTestToken(t, tokenMaker, user.Username, token.AudienceRefresh, time.Hour)
+			},
+			func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(randomSession(payload, "other token"), nil)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			"Unauthorized expired session",
+			func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				return createTestToken(t, tokenMaker, user.Username, token.AudienceRefresh, time.Hour)
+			},
+			func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				session := randomSession(payload, refreshToken)
+				session.ExpiresAt = time.Now().Add(-time.Minute)
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(session, nil)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			"InternalError",
+			func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				return createTestToken(t, tokenMaker, user.Username, token.AudienceRefresh, time.Hour)
+			},
+			func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Session{}, sql.ErrConnDone)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+
+			refreshToken, payload := tc.buildToken(t, server.tokenMaker)
+			tc.buildStubs(store, refreshToken, payload)
+
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{"refresh_token": refreshToken})
+			assert.NoError(t, err)
+
+			url := "/tokens/renew_access"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			assert.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// createTestToken creates a token and its payload for the given username and audience
+func createTestToken(
+	t *testing.T,
+	tokenMaker token.Maker,
+	username string,
+	audience string,
+	duration time.Duration,
+) (string, *token.Payload) {
+	token, payload, err := tokenMaker.CreateToken(username, audience, duration)
+	assert.NoError(t, err)
+	return token, payload
+}
+
+// randomSession returns a session matching the refresh token and its payload
+func randomSession(payload *token.Payload, refreshToken string) db.Session {
+	return db.Session{
+		ID:           payload.ID,
+		Username:     payload.Username,
+		RefreshToken: refreshToken,
+		UserAgent:    randomString(10),
+		ClientIp:     "127.0.0.1",
+		ExpiresAt:    payload.ExpiredAt,
+		CreatedAt:    payload.IssuedAt,
+	}
+}
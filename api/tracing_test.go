@@ -0,0 +1,101 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/peienxie/go-bank/db/mock"
+	db "github.com/peienxie/go-bank/db/sqlc"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracingMiddleware(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	}()
+
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	clientTraceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	testCases := []struct {
+		name       string
+		buildStubs func(store *mockdb.MockStore)
+		checkSpan  func(t *testing.T, span tracetest.SpanStub)
+	}{
+		{
+			"OK",
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).
+					DoAndReturn(func(ctx context.Context, id int64) (db.Account, error) {
+						// the span of the request is carried to the store
+						assert.Equal(t, clientTraceID, trace.SpanContextFromContext(ctx).TraceID().String())
+						return account, nil
+					})
+				store.EXPECT().GetAccountHeldAmount(gomock.Any(), account.ID).Times(1).Return(int64(0), nil)
+			},
+			func(t *testing.T, span tracetest.SpanStub) {
+				assert.Equal(t, codes.Unset, span.Status.Code)
+				assert.Contains(t, span.Attributes, attribute.Int("http.status_code", http.StatusOK))
+			},
+		},
+		{
+			"InternalError",
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(db.Account{}, sql.ErrConnDone)
+			},
+			func(t *testing.T, span tracetest.SpanStub) {
+				assert.Equal(t, codes.Error, span.Status.Code)
+				assert.Contains(t, span.Attributes, attribute.Int("http.status_code", http.StatusInternalServerError))
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			exporter.Reset()
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/accounts/%d", account.ID), nil)
+			assert.NoError(t, err)
+			request.Header.Set("traceparent", fmt.Sprintf("00-%s-00f067aa0ba902b7-01", clientTraceID))
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+
+			server.router.ServeHTTP(recorder, request)
+
+			spans := exporter.GetSpans()
+			assert.Len(t, spans, 1)
+			span := spans[0]
+			assert.Equal(t, "GET /accounts/:id", span.Name)
+			assert.Equal(t, trace.SpanKindServer, span.SpanKind)
+			assert.Equal(t, clientTraceID, span.SpanContext.TraceID().String())
+			assert.Equal(t, "00f067aa0ba902b7", span.Parent.SpanID().String())
+			tc.checkSpan(t, span)
+		})
+	}
+}
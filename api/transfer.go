@@ -1,69 +1,616 @@
 package api
 
 import (
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	db "github.com/peienxie/go-bank/db/sqlc"
+	"github.com/peienxie/go-bank/fx"
+	"github.com/peienxie/go-bank/logging"
+	"go.uber.org/zap"
 )
 
+// idempotencyKeyHeader is the request header that makes transfer creation safe to retry
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength limits the size of the idempotency key stored in db
+const maxIdempotencyKeyLength = 255
+
+// idempotentReplayedHeader is set on the response replayed from a previous request
+const idempotentReplayedHeader = "Idempotent-Replayed"
+
 func (s *Server) initTransferRoutes() {
-	s.router.POST("/transfers", s.createTransfer)
+	authRoutes := s.router.Group("/").Use(authMiddleware(s.tokenMaker))
+	authRoutes.POST("/transfers", s.createTransfer)
+	authRoutes.POST("/transfers/pending", s.createPendingTransfer)
+	authRoutes.GET("/transfers/:id", s.getTransfer)
+	authRoutes.GET("/transfers/:id/status_changes", s.listTransferStatusChanges)
+	authRoutes.GET("/transfers", s.listTransfers)
+	authRoutes.POST("/transfers/:id/reversal", s.reverseTransfer)
+
+	operatorRoutes := s.router.Group("/operator").Use(authMiddleware(s.tokenMaker), operatorMiddleware(s.store))
+	operatorRoutes.POST("/transfers/:id/reversal", s.operatorReverseTransfer)
+	operatorRoutes.POST("/transfers/:id/settlement", s.settleTransfer)
+	operatorRoutes.POST("/transfers/:id/failure", s.failTransfer)
 }
 
 type createTransferRequest struct {
 	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
 	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
 	Amount        int64  `json:"amount" binding:"required,gt=0"`
-	Currency      string `json:"currency" binding:"required,oneof=USD TWD"`
+	Currency      string `json:"currency" binding:"required,currency"`
 }
 
 func (s *Server) createTransfer(c *gin.Context) {
 	var req createTransferRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse(err))
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	idempotencyKey := c.GetHeader(idempotencyKeyHeader)
+	if len(idempotencyKey) > maxIdempotencyKeyLength {
+		message := fmt.Sprintf("%s header must be at most %d characters", idempotencyKeyHeader, maxIdempotencyKeyLength)
+		abortWithError(c, newError(http.StatusBadRequest, errCodeInvalidRequest, message))
 		return
 	}
 
-	if ok := s.validAccount(c, req.FromAccountID, req.Currency); !ok {
+	fromAccount, ok := s.validAccount(c, req.FromAccountID, req.Currency)
+	if !ok {
 		return
 	}
 
-	if ok := s.validAccount(c, req.ToAccountID, req.Currency); !ok {
+	payload := authPayload(c)
+	if fromAccount.Username != payload.Username {
+		abortWithError(c, forbiddenError("from account doesn't belong to the authenticated user"))
 		return
 	}
 
+	toAccount, ok := s.existingAccount(c, req.ToAccountID)
+	if !ok {
+		return
+	}
+
+	// transfers to an account of another currency are converted with the current exchange rate
+	var exchangeRate string
+	if toAccount.Currency != fromAccount.Currency {
+		rate, err := s.rates.Rate(c.Request.Context(), fromAccount.Currency, toAccount.Currency)
+		if err != nil {
+			if errors.Is(err, fx.ErrRateNotFound) {
+				message := fmt.Sprintf("no exchange rate from %s to %s", fromAccount.Currency, toAccount.Currency)
+				err = newError(http.StatusUnprocessableEntity, errCodeExchangeRateUnavailable, message)
+			}
+			abortWithError(c, err)
+			return
+		}
+		exchangeRate = rate.Rate
+	}
+
 	arg := db.TransferTxParams{
 		FromAccountID: req.FromAccountID,
 		ToAccountID:   req.ToAccountID,
 		Amount:        req.Amount,
 	}
-	result, err := s.store.TransferTx(c, arg)
+	var result db.TransferTxResult
+	var err error
+	switch {
+	case idempotencyKey != "":
+		result, err = s.idempotentTransfer(c, payload.Username, idempotencyKey, req, arg, exchangeRate)
+	case exchangeRate != "":
+		result, err = s.store.FXTransferTx(c.Request.Context(), db.FXTransferTxParams{
+			TransferTxParams: arg,
+			ExchangeRate:     exchangeRate,
+		})
+	default:
+		result, err = s.store.TransferTx(c.Request.Context(), arg)
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, s.newTransferTxResponse(result))
+}
+
+// createPendingTransfer records a transfer between accounts of the same currency without moving
+// any money, the transfer is held for review until an operator settles or fails it
+func (s *Server) createPendingTransfer(c *gin.Context) {
+	var req createTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	fromAccount, ok := s.validAccount(c, req.FromAccountID, req.Currency)
+	if !ok {
+		return
+	}
+
+	payload := authPayload(c)
+	if fromAccount.Username != payload.Username {
+		abortWithError(c, forbiddenError("from account doesn't belong to the authenticated user"))
+		return
+	}
+
+	if _, ok := s.validAccount(c, req.ToAccountID, req.Currency); !ok {
+		return
+	}
+
+	transfer, err := s.store.AuthorizeTransferTx(c.Request.Context(), db.TransferTxParams{
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+	})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, s.newFormattedTransferResponse(transfer, req.Currency, req.Currency))
+}
+
+// transferResponse is the transfer along with its amounts rendered in the currencies of the accounts,
+// a formatted amount is left out if the currency of its account isn't known by the handler.
+// ReversedAt is the time of the latest reversal, it is only present once the transfer is reversed
+type transferResponse struct {
+	ID                int64
+	FromAccountID     int64
+	ToAccountID       int64
+	Amount            int64
+	CreatedAt         time.Time
+	ToAmount          int64
+	ExchangeRate      string
+	ReversedAmount    int64
+	ReversedAt        *time.Time `json:",omitempty"`
+	Status            db.TransferStatus
+	FormattedAmount   string `json:",omitempty"`
+	FormattedToAmount string `json:",omitempty"`
+}
+
+func newTransferResponse(transfer db.Transfer) transferResponse {
+	resp := transferResponse{
+		ID:             transfer.ID,
+		FromAccountID:  transfer.FromAccountID,
+		ToAccountID:    transfer.ToAccountID,
+		Amount:         transfer.Amount,
+		CreatedAt:      transfer.CreatedAt,
+		ToAmount:       transfer.ToAmount,
+		ExchangeRate:   transfer.ExchangeRate,
+		ReversedAmount: transfer.ReversedAmount,
+		Status:         transfer.Status,
+	}
+	if transfer.ReversedAt.Valid {
+		resp.ReversedAt = &transfer.ReversedAt.Time
+	}
+	return resp
+}
+
+// newFormattedTransferResponse renders the amounts of the transfer in the currencies of its accounts
+func (s *Server) newFormattedTransferResponse(transfer db.Transfer, fromCurrency, toCurrency string) transferResponse {
+	resp := newTransferResponse(transfer)
+	resp.FormattedAmount = s.formatAmount(fromCurrency, transfer.Amount)
+	resp.FormattedToAmount = s.formatAmount(toCurrency, transfer.ToAmount)
+	return resp
+}
+
+// transferTxResponse is the result of a transfer with the amounts rendered in the currencies of the accounts
+type transferTxResponse struct {
+	Transfer    transferResponse `json:"transfer"`
+	FromAccount accountResponse  `json:"from_account"`
+	ToAccount   accountResponse  `json:"to_account"`
+	FromEntry   entryResponse    `json:"from_entry"`
+	ToEntry     entryResponse    `json:"to_entry"`
+}
+
+func (s *Server) newTransferTxResponse(result db.TransferTxResult) transferTxResponse {
+	resp := transferTxResponse{
+		Transfer:    s.newFormattedTransferResponse(result.Transfer, result.FromAccount.Currency, result.ToAccount.Currency),
+		FromAccount: s.newAccountResponse(result.FromAccount),
+		ToAccount:   s.newAccountResponse(result.ToAccount),
+		FromEntry:   s.newEntryResponse(result.FromEntry, result.FromAccount.Currency),
+		ToEntry:     s.newEntryResponse(result.ToEntry, result.ToAccount.Currency),
+	}
+	resp.FromEntry.CounterpartyAccountID = &result.Transfer.ToAccountID
+	resp.ToEntry.CounterpartyAccountID = &result.Transfer.FromAccountID
+	return resp
+}
+
+// newAccountTransferResponses renders the amounts of the transfers listed for the account,
+// only the side of the account is formatted since the currency of the other side isn't loaded
+func (s *Server) newAccountTransferResponses(account db.Account, transfers []db.Transfer) []transferResponse {
+	resp := make([]transferResponse, len(transfers))
+	for i, transfer := range transfers {
+		resp[i] = newTransferResponse(transfer)
+		if transfer.FromAccountID == account.ID {
+			resp[i].FormattedAmount = s.formatAmount(account.Currency, transfer.Amount)
+		}
+		if transfer.ToAccountID == account.ID {
+			resp[i].FormattedToAmount = s.formatAmount(account.Currency, transfer.ToAmount)
+		}
+	}
+	return resp
+}
+
+// idempotentTransfer performs the transfer once per idempotency key of the user,
+// and replays the stored result when the same request is sent again
+func (s *Server) idempotentTransfer(
+	c *gin.Context,
+	username string,
+	idempotencyKey string,
+	req createTransferRequest,
+	arg db.TransferTxParams,
+	exchangeRate string,
+) (db.TransferTxResult, error) {
+	requestHash, err := hashRequest(req)
+	if err != nil {
+		return db.TransferTxResult{}, err
+	}
+
+	result, err := s.store.IdempotentTransferTx(c.Request.Context(), db.IdempotentTransferTxParams{
+		TransferTxParams: arg,
+		Username:         username,
+		IdempotencyKey:   idempotencyKey,
+		RequestHash:      requestHash,
+		ExchangeRate:     exchangeRate,
+	})
+	if err != nil {
+		return db.TransferTxResult{}, err
+	}
+
+	if result.Replayed {
+		c.Header(idempotentReplayedHeader, "true")
+	}
+	return result.TransferTxResult, nil
+}
+
+// hashRequest returns the hex encoded SHA-256 hash of the JSON encoded request
+func hashRequest(req interface{}) (string, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+type getTransferRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (s *Server) getTransfer(c *gin.Context) {
+	var req getTransferRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	transfer, fromAccount, toAccount, ok := s.visibleTransfer(c, req.ID)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, s.newFormattedTransferResponse(transfer, fromAccount.Currency, toAccount.Currency))
+}
+
+// listTransferStatusChanges lists the statuses the transfer went through from the oldest
+func (s *Server) listTransferStatusChanges(c *gin.Context) {
+	var req getTransferRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	if _, _, _, ok := s.visibleTransfer(c, req.ID); !ok {
+		return
+	}
+
+	changes, err := s.store.ListTransferStatusChanges(c.Request.Context(), req.ID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, changes)
+}
+
+// visibleTransfer loads the transfer along with its accounts and checks it's visible to the authenticated user,
+// the transfer is visible to the owners of both sides
+func (s *Server) visibleTransfer(c *gin.Context, id int64) (transfer db.Transfer, fromAccount, toAccount db.Account, ok bool) {
+	transfer, err := s.store.GetTransfer(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = notFoundError(errCodeTransferNotFound, "transfer")
+		}
+		abortWithError(c, err)
+		return
+	}
+
+	fromAccount, err = s.store.GetAccount(c.Request.Context(), transfer.FromAccountID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	toAccount, err = s.store.GetAccount(c.Request.Context(), transfer.ToAccountID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	payload := authPayload(c)
+	if fromAccount.Username != payload.Username && toAccount.Username != payload.Username {
+		abortWithError(c, forbiddenError("transfer doesn't belong to the authenticated user"))
+		return
+	}
+	return transfer, fromAccount, toAccount, true
+}
+
+// settleTransfer moves the money of a pending transfer once it's approved by an operator,
+// the transfer is failed instead if the sender no longer has sufficient funds
+func (s *Server) settleTransfer(c *gin.Context) {
+	var req getTransferRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	logging.FromContext(c.Request.Context()).Info("operator settling transfer",
+		zap.String("operator", authPayload(c).Username),
+		zap.Int64("transfer_id", req.ID),
+	)
+	result, err := s.store.SettleTransferTx(c.Request.Context(), req.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = notFoundError(errCodeTransferNotFound, "transfer")
+		}
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, s.newTransferTxResponse(result))
+}
+
+// failTransfer rejects a pending transfer without moving any money
+func (s *Server) failTransfer(c *gin.Context) {
+	var req getTransferRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	logging.FromContext(c.Request.Context()).Info("operator failing transfer",
+		zap.String("operator", authPayload(c).Username),
+		zap.Int64("transfer_id", req.ID),
+	)
+	transfer, err := s.store.FailTransferTx(c.Request.Context(), req.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = notFoundError(errCodeTransferNotFound, "transfer")
+		}
+		abortWithError(c, err)
+		return
+	}
+
+	fromAccount, err := s.store.GetAccount(c.Request.Context(), transfer.FromAccountID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	toAccount, err := s.store.GetAccount(c.Request.Context(), transfer.ToAccountID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, s.newFormattedTransferResponse(transfer, fromAccount.Currency, toAccount.Currency))
+}
+
+type reverseTransferRequest struct {
+	// Amount is optional, the rest of the transfer is reversed if it's not provided
+	Amount int64 `json:"amount" binding:"omitempty,gt=0"`
+}
+
+// reverseTransfer moves the money of a transfer back to the sender,
+// it's requested by the owner of the receiving account as a refund.
+// The sender can't reverse its own transfer since the money already belongs to the receiver,
+// a mistaken transfer is reversed by an operator through operatorReverseTransfer instead
+func (s *Server) reverseTransfer(c *gin.Context) {
+	transfer, req, ok := s.bindReversalRequest(c)
+	if !ok {
+		return
+	}
+
+	toAccount, err := s.store.GetAccount(c.Request.Context(), transfer.ToAccountID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	payload := authPayload(c)
+	if toAccount.Username != payload.Username {
+		abortWithError(c, forbiddenError("to account doesn't belong to the authenticated user"))
+		return
+	}
+
+	s.reverse(c, transfer, req.Amount)
+}
+
+// operatorReverseTransfer moves the money of any transfer back to the sender,
+// it's requested by support staff undoing a mistaken transfer
+func (s *Server) operatorReverseTransfer(c *gin.Context) {
+	transfer, req, ok := s.bindReversalRequest(c)
+	if !ok {
+		return
+	}
+
+	logging.FromContext(c.Request.Context()).Info("operator reversing transfer",
+		zap.String("operator", authPayload(c).Username),
+		zap.Int64("transfer_id", transfer.ID),
+		zap.Int64("amount", req.Amount),
+	)
+	s.reverse(c, transfer, req.Amount)
+}
+
+// bindReversalRequest binds the reversal request and loads the transfer to reverse
+func (s *Server) bindReversalRequest(c *gin.Context) (db.Transfer, reverseTransferRequest, bool) {
+	var uri getTransferRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		abortWithError(c, bindingError(err))
+		return db.Transfer{}, reverseTransferRequest{}, false
+	}
+
+	var req reverseTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		abortWithError(c, bindingError(err))
+		return db.Transfer{}, reverseTransferRequest{}, false
+	}
+
+	transfer, err := s.store.GetTransfer(c.Request.Context(), uri.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = notFoundError(errCodeTransferNotFound, "transfer")
+		}
+		abortWithError(c, err)
+		return db.Transfer{}, reverseTransferRequest{}, false
+	}
+	return transfer, req, true
+}
+
+// reverse reverses the amount of the transfer, the rest of the transfer is reversed if amount is 0
+func (s *Server) reverse(c *gin.Context, transfer db.Transfer, amount int64) {
+	result, err := s.store.ReverseTransferTx(c.Request.Context(), db.ReverseTransferTxParams{
+		TransferID: transfer.ID,
+		Amount:     amount,
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		abortWithError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, result)
+	c.JSON(http.StatusOK, s.newTransferTxResponse(result))
 }
 
-func (s *Server) validAccount(c *gin.Context, id int64, currency string) bool {
-	account, err := s.store.GetAccount(c, id)
+// Directions of the transfers to list relative to the account,
+// transfers of both directions are listed if it's not provided
+const (
+	transferDirectionIn  = "in"
+	transferDirectionOut = "out"
+	transferDirectionAll = "all"
+)
+
+type listTransfersRequest struct {
+	pageRequest
+	AccountID int64  `form:"account_id" binding:"required,min=1"`
+	Direction string `form:"direction" binding:"omitempty,oneof=in out all"`
+}
+
+func (s *Server) listTransfers(c *gin.Context) {
+	var req listTransfersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	account, err := s.store.GetAccount(c.Request.Context(), req.AccountID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, errorResponse(err))
-			return false
+		if errors.Is(err, sql.ErrNoRows) {
+			err = notFoundError(errCodeAccountNotFound, "account")
 		}
-		c.JSON(http.StatusInternalServerError, errorResponse(err))
-		return false
+		abortWithError(c, err)
+		return
+	}
+
+	payload := authPayload(c)
+	if account.Username != payload.Username {
+		abortWithError(c, forbiddenError("account doesn't belong to the authenticated user"))
+		return
+	}
+
+	// account ID 0 never matches, which excludes the other direction
+	var fromAccountID, toAccountID int64
+	switch req.Direction {
+	case transferDirectionIn:
+		toAccountID = account.ID
+	case transferDirectionOut:
+		fromAccountID = account.ID
+	case transferDirectionAll, "":
+		fromAccountID = account.ID
+		toAccountID = account.ID
+	}
+
+	if req.useOffset() {
+		arg := db.ListTransfersByAccountParams{
+			FromAccountID: fromAccountID,
+			ToAccountID:   toAccountID,
+			Limit:         req.PageSize,
+			Offset:        req.offset(),
+		}
+		transfers, err := s.store.ListTransfersByAccount(c.Request.Context(), arg)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, s.newAccountTransferResponses(account, transfers))
+		return
+	}
+
+	cursor, err := decodeCursor(req.Cursor)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	// fetch one extra row to find out whether there is a next page
+	arg := db.ListTransfersByAccountAfterParams{
+		FromAccountID: fromAccountID,
+		ToAccountID:   toAccountID,
+		Cursor:        cursor,
+		Limit:         req.PageSize + 1,
+	}
+	transfers, err := s.store.ListTransfersByAccountAfter(c.Request.Context(), arg)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	var resp pageResponse
+	if len(transfers) > int(req.PageSize) {
+		transfers = transfers[:req.PageSize]
+		resp.NextCursor = encodeCursor(transfers[len(transfers)-1].ID)
+	}
+	resp.Items = s.newAccountTransferResponses(account, transfers)
+	c.JSON(http.StatusOK, resp)
+}
+
+// existingAccount checks the account exists
+func (s *Server) existingAccount(c *gin.Context, id int64) (db.Account, bool) {
+	account, err := s.store.GetAccount(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = notFoundError(errCodeAccountNotFound, "account")
+		}
+		abortWithError(c, err)
+		return account, false
+	}
+	return account, true
+}
+
+// validAccount checks the account exists and its currency matches the provided currency
+func (s *Server) validAccount(c *gin.Context, id int64, currency string) (db.Account, bool) {
+	account, ok := s.existingAccount(c, id)
+	if !ok {
+		return account, false
 	}
 	if account.Currency != currency {
-		err := fmt.Errorf("account %d currency expect %s, but got %s",
-			id, currency, account.Currency)
-		c.JSON(http.StatusBadRequest, errorResponse(err))
-		return false
+		abortWithError(c, currencyMismatchError(id, currency, account.Currency))
+		return account, false
 	}
-	return true
+	return account, true
 }
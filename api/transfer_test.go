@@ -4,22 +4,28 @@ import (
 	"bytes"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	mockdb "github.com/peienxie/go-bank/db/mock"
 	db "github.com/peienxie/go-bank/db/sqlc"
+	"github.com/peienxie/go-bank/token"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestCreateTransferAPI(t *testing.T) {
 	amount := randomMoney()
-	account1 := randomAccount()
-	account2 := randomAccount()
-	otherCurrencyAccount := randomAccount()
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	otherCurrencyAccount := randomAccount(user1.Username)
 
 	currency := "USD"
 	otherCurrency := "TWD"
@@ -31,6 +37,7 @@ func TestCreateTransferAPI(t *testing.T) {
 	testCases := []struct {
 		name          string
 		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
 		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
@@ -42,6 +49,9 @@ func TestCreateTransferAPI(t *testing.T) {
 				"amount":          amount,
 				"currency":        currency,
 			},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
 			func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(account1, nil)
 				store.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(1).Return(account2, nil)
@@ -56,6 +66,24 @@ func TestCreateTransferAPI(t *testing.T) {
 				assert.Equal(t, http.StatusOK, recorder.Code)
 			},
 		},
+		{
+			"Unauthorized no authorization",
+			gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        currency,
+			},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
 		{
 			"BadRequest request from account id invalid",
 			gin.H{
@@ -64,6 +92,9 @@ func TestCreateTransferAPI(t *testing.T) {
 				"amount":          amount,
 				"currency":        currency,
 			},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
 			func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(0)
 				store.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(0)
@@ -86,6 +117,9 @@ func TestCreateTransferAPI(t *testing.T) {
 				"amount":          amount,
 				"currency":        currency,
 			},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
 			func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(0)
 				store.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(0)
@@ -108,6 +142,9 @@ func TestCreateTransferAPI(t *testing.T) {
 				"amount":          0,
 				"currency":        currency,
 			},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
 			func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(0)
 				store.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(0)
@@ -130,6 +167,9 @@ func TestCreateTransferAPI(t *testing.T) {
 				"amount":          amount,
 				"currency":        invalidCurrency,
 			},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
 			func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(0)
 				store.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(0)
@@ -152,6 +192,9 @@ func TestCreateTransferAPI(t *testing.T) {
 				"amount":          amount,
 				"currency":        currency,
 			},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
 			func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(db.Account{}, sql.ErrNoRows)
 				store.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(0)
@@ -166,6 +209,26 @@ func TestCreateTransferAPI(t *testing.T) {
 				assert.Equal(t, http.StatusNotFound, recorder.Code)
 			},
 		},
+		{
+			"Forbidden from account not owned by user",
+			gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        currency,
+			},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
 		{
 			"NotFound to acount not found",
 			gin.H{
@@ -174,6 +237,9 @@ func TestCreateTransferAPI(t *testing.T) {
 				"amount":          amount,
 				"currency":        currency,
 			},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
 			func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(account1, nil)
 				store.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(1).Return(db.Account{}, sql.ErrNoRows)
@@ -196,6 +262,9 @@ func TestCreateTransferAPI(t *testing.T) {
 				"amount":          amount,
 				"currency":        currency,
 			},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
 			func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(account1, nil)
 				store.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(1).Return(db.Account{}, sql.ErrConnDone)
@@ -218,6 +287,9 @@ func TestCreateTransferAPI(t *testing.T) {
 				"amount":          amount,
 				"currency":        currency,
 			},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
 			func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(otherCurrencyAccount, nil)
 				store.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(0)
@@ -233,25 +305,131 @@ func TestCreateTransferAPI(t *testing.T) {
 			},
 		},
 		{
-			"BadRequest to account currency not match",
+			"OK cross-currency transfer",
+			gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   otherCurrencyAccount.ID,
+				"amount":          amount,
+				"currency":        currency,
+			},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), otherCurrencyAccount.ID).Times(1).Return(otherCurrencyAccount, nil)
+				rateArg := db.GetExchangeRateParams{
+					FromCurrency: currency,
+					ToCurrency:   otherCurrency,
+				}
+				rate := db.ExchangeRate{FromCurrency: currency, ToCurrency: otherCurrency, Rate: "30.5"}
+				store.EXPECT().GetExchangeRate(gomock.Any(), rateArg).Times(1).Return(rate, nil)
+				arg := db.FXTransferTxParams{
+					TransferTxParams: db.TransferTxParams{
+						FromAccountID: account1.ID,
+						ToAccountID:   otherCurrencyAccount.ID,
+						Amount:        amount,
+					},
+					ExchangeRate: rate.Rate,
+				}
+				store.EXPECT().FXTransferTx(gomock.Any(), arg).Times(1)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			"UnprocessableEntity converted amount too small",
+			gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   otherCurrencyAccount.ID,
+				"amount":          amount,
+				"currency":        currency,
+			},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), otherCurrencyAccount.ID).Times(1).Return(otherCurrencyAccount, nil)
+				rate := db.ExchangeRate{FromCurrency: currency, ToCurrency: otherCurrency, Rate: "0.0001"}
+				store.EXPECT().GetExchangeRate(gomock.Any(), gomock.Any()).Times(1).Return(rate, nil)
+				store.EXPECT().FXTransferTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.TransferTxResult{}, db.ErrConvertedAmountTooSmall)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				checkErrorCode(t, recorder.Body, errCodeConvertedAmountTooSmall)
+			},
+		},
+		{
+			"UnprocessableEntity exchange rate unavailable",
+			gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   otherCurrencyAccount.ID,
+				"amount":          amount,
+				"currency":        currency,
+			},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), otherCurrencyAccount.ID).Times(1).Return(otherCurrencyAccount, nil)
+				store.EXPECT().GetExchangeRate(gomock.Any(), gomock.Any()).Times(1).Return(db.ExchangeRate{}, sql.ErrNoRows)
+				store.EXPECT().FXTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				checkErrorCode(t, recorder.Body, errCodeExchangeRateUnavailable)
+			},
+		},
+		{
+			"InternalServerError get exchange rate error",
+			gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   otherCurrencyAccount.ID,
+				"amount":          amount,
+				"currency":        currency,
+			},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), otherCurrencyAccount.ID).Times(1).Return(otherCurrencyAccount, nil)
+				store.EXPECT().GetExchangeRate(gomock.Any(), gomock.Any()).Times(1).Return(db.ExchangeRate{}, sql.ErrConnDone)
+				store.EXPECT().FXTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			"UnprocessableEntity insufficient funds",
 			gin.H{
 				"from_account_id": account1.ID,
 				"to_account_id":   account2.ID,
 				"amount":          amount,
 				"currency":        currency,
 			},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
 			func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(account1, nil)
-				store.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(1).Return(otherCurrencyAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(1).Return(account2, nil)
 				arg := db.TransferTxParams{
 					FromAccountID: account1.ID,
 					ToAccountID:   account2.ID,
 					Amount:        amount,
 				}
-				store.EXPECT().TransferTx(gomock.Any(), arg).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), arg).Times(1).Return(db.TransferTxResult{}, db.ErrInsufficientFunds)
 			},
 			func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+				assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				checkErrorCode(t, recorder.Body, errCodeInsufficientFunds)
 			},
 		},
 		{
@@ -262,6 +440,9 @@ func TestCreateTransferAPI(t *testing.T) {
 				"amount":          amount,
 				"currency":        currency,
 			},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
 			func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(account1, nil)
 				store.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(1).Return(account2, nil)
@@ -285,7 +466,7 @@ func TestCreateTransferAPI(t *testing.T) {
 			store := mockdb.NewMockStore(ctrl)
 			tc.buildStubs(store)
 
-			server := NewServer(store)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 
 			data, err := json.Marshal(tc.body)
@@ -295,8 +476,1242 @@ func TestCreateTransferAPI(t *testing.T) {
 			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
 			assert.NoError(t, err)
 
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestCreateTransferIdempotencyAPI(t *testing.T) {
+	amount := randomMoney()
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account1.Currency = "USD"
+	account2.Currency = "USD"
+
+	req := createTransferRequest{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount,
+		Currency:      "USD",
+	}
+	requestHash, err := hashRequest(req)
+	assert.NoError(t, err)
+
+	idempotencyKey := randomString(16)
+	arg := db.IdempotentTransferTxParams{
+		TransferTxParams: db.TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        amount,
+		},
+		Username:       user1.Username,
+		IdempotencyKey: idempotencyKey,
+		RequestHash:    requestHash,
+	}
+
+	testCases := []struct {
+		name           string
+		idempotencyKey string
+		buildStubs     func(store *mockdb.MockStore)
+		checkResponse  func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			"OK",
+			idempotencyKey,
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().IdempotentTransferTx(gomock.Any(), arg).Times(1).
+					Return(db.IdempotentTransferTxResult{}, nil)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+				assert.Empty(t, recorder.Header().Get(idempotentReplayedHeader))
+			},
+		},
+		{
+			"OK replayed",
+			idempotencyKey,
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(1).Return(account2, nil)
+				store.EXPECT().IdempotentTransferTx(gomock.Any(), arg).Times(1).
+					Return(db.IdempotentTransferTxResult{Replayed: true}, nil)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+				assert.Equal(t, "true", recorder.Header().Get(idempotentReplayedHeader))
+			},
+		},
+		{
+			"Conflict idempotency key reused",
+			idempotencyKey,
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(1).Return(account2, nil)
+				store.EXPECT().IdempotentTransferTx(gomock.Any(), arg).Times(1).
+					Return(db.IdempotentTransferTxResult{}, db.ErrIdempotencyKeyReused)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusConflict, recorder.Code)
+				checkErrorCode(t, recorder.Body, errCodeIdempotencyKeyReused)
+			},
+		},
+		{
+			"BadRequest idempotency key too long",
+			randomString(maxIdempotencyKeyLength + 1),
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().IdempotentTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(req)
+			assert.NoError(t, err)
+
+			url := "/transfers"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			assert.NoError(t, err)
+
+			request.Header.Set(idempotencyKeyHeader, tc.idempotencyKey)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestGetTransferAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+	account1 := randomAccount(user1.Username)
+	account1.Currency = "USD"
+	account2 := randomAccount(user2.Username)
+	account2.Currency = "TWD"
+	account2.ID = account1.ID + 1
+	transfer := db.Transfer{
+		ID:            randomInt(1, 1000),
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1234,
+		ToAmount:      40000,
+		ExchangeRate:  "32.4149",
+	}
+
+	testCases := []struct {
+		name          string
+		transferID    int64
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			"OK sender",
+			transfer.ID,
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), transfer.ID).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(1).Return(account2, nil)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+
+				var got transferResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				assert.NoError(t, err)
+				assert.Equal(t, transfer.ID, got.ID)
+				assert.Equal(t, transfer.Amount, got.Amount)
+				assert.Equal(t, transfer.ToAmount, got.ToAmount)
+				assert.Equal(t, transfer.ExchangeRate, got.ExchangeRate)
+				assert.Nil(t, got.ReversedAt)
+				assert.Equal(t, "$12.34", got.FormattedAmount)
+				assert.Equal(t, "NT$400.00", got.FormattedToAmount)
+			},
+		},
+		{
+			"OK receiver",
+			transfer.ID,
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), transfer.ID).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(1).Return(account2, nil)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			"Forbidden transfer not owned by user",
+			transfer.ID,
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), transfer.ID).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(1).Return(account2, nil)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			"NotFound",
+			transfer.ID,
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), transfer.ID).Times(1).Return(db.Transfer{}, sql.ErrNoRows)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			"BadRequest",
+			-1,
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			"Unauthorized no authorization",
+			transfer.ID,
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			"InternalError",
+			transfer.ID,
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), transfer.ID).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(db.Account{}, sql.ErrConnDone)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/transfers/%d", tc.transferID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			assert.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
 			server.router.ServeHTTP(recorder, request)
 			tc.checkResponse(t, recorder)
 		})
 	}
 }
+
+func TestReverseTransferAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account2.ID = account1.ID + 1
+	transfer := db.Transfer{
+		ID:            randomInt(1, 1000),
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        randomMoney(),
+	}
+	transfer.ToAmount = transfer.Amount
+
+	testCases := []struct {
+		name          string
+		transferID    int64
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			"OK full reversal",
+			transfer.ID,
+			nil,
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				arg := db.ReverseTransferTxParams{TransferID: transfer.ID}
+				store.EXPECT().GetTransfer(gomock.Any(), transfer.ID).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(1).Return(account2, nil)
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			"OK partial reversal",
+			transfer.ID,
+			gin.H{"amount": 1},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				arg := db.ReverseTransferTxParams{TransferID: transfer.ID, Amount: 1}
+				store.EXPECT().GetTransfer(gomock.Any(), transfer.ID).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(1).Return(account2, nil)
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			"Forbidden sender can't reverse",
+			transfer.ID,
+			nil,
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), transfer.ID).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(1).Return(account2, nil)
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// only the receiver or an operator can reverse the transfer
+				assert.Equal(t, http.StatusForbidden, recorder.Code)
+				checkErrorCode(t, recorder.Body, errCodeForbidden)
+			},
+		},
+		{
+			"Conflict already reversed",
+			transfer.ID,
+			nil,
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), transfer.ID).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(1).Return(account2, nil)
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.TransferTxResult{}, db.ErrTransferAlreadyReversed)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusConflict, recorder.Code)
+				checkErrorCode(t, recorder.Body, errCodeTransferAlreadyReversed)
+			},
+		},
+		{
+			"Conflict transfer not completed",
+			transfer.ID,
+			nil,
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), transfer.ID).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(1).Return(account2, nil)
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.TransferTxResult{}, db.ErrInvalidTransferTransition)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusConflict, recorder.Code)
+				checkErrorCode(t, recorder.Body, errCodeInvalidTransferStatus)
+			},
+		},
+		{
+			"UnprocessableEntity amount exceeds transfer",
+			transfer.ID,
+			gin.H{"amount": transfer.Amount + 1},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), transfer.ID).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(1).Return(account2, nil)
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.TransferTxResult{}, db.ErrInvalidReversalAmount)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				checkErrorCode(t, recorder.Body, errCodeInvalidReversalAmount)
+			},
+		},
+		{
+			"UnprocessableEntity insufficient funds",
+			transfer.ID,
+			nil,
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), transfer.ID).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(1).Return(account2, nil)
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.TransferTxResult{}, db.ErrInsufficientFunds)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				checkErrorCode(t, recorder.Body, errCodeInsufficientFunds)
+			},
+		},
+		{
+			"BadRequest negative amount",
+			transfer.ID,
+			gin.H{"amount": -1},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			"NotFound",
+			transfer.ID,
+			nil,
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), transfer.ID).Times(1).Return(db.Transfer{}, sql.ErrNoRows)
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			"InternalError",
+			transfer.ID,
+			nil,
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), transfer.ID).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(1).Return(account2, nil)
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.TransferTxResult{}, sql.ErrConnDone)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			var body bytes.Buffer
+			if tc.body != nil {
+				assert.NoError(t, json.NewEncoder(&body).Encode(tc.body))
+			}
+
+			url := fmt.Sprintf("/transfers/%d/reversal", tc.transferID)
+			request, err := http.NewRequest(http.MethodPost, url, &body)
+			assert.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestOperatorReverseTransferAPI(t *testing.T) {
+	operator, _ := randomUser(t)
+	operator.Role = db.UserRoleOperator
+	customer, _ := randomUser(t)
+	transfer := db.Transfer{
+		ID:            randomInt(1, 1000),
+		FromAccountID: randomInt(1, 1000),
+		ToAccountID:   randomInt(1001, 2000),
+		Amount:        randomMoney(),
+	}
+	transfer.ToAmount = transfer.Amount
+	transferID := sql.NullInt64{Int64: transfer.ID, Valid: true}
+	reversal := db.TransferTxResult{
+		Transfer:  transfer,
+		FromEntry: db.Entry{AccountID: transfer.FromAccountID, Amount: transfer.Amount, TransferID: transferID, Type: db.EntryTypeReversal},
+		ToEntry:   db.Entry{AccountID: transfer.ToAccountID, Amount: -transfer.ToAmount, TransferID: transferID, Type: db.EntryTypeReversal},
+	}
+	reversal.Transfer.ReversedAmount = transfer.Amount
+	reversal.Transfer.ReversedAt = sql.NullTime{Time: time.Date(2022, time.March, 1, 9, 0, 0, 0, time.UTC), Valid: true}
+	reversal.Transfer.Status = db.TransferStatusReversed
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			"OK",
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, operator.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				arg := db.ReverseTransferTxParams{TransferID: transfer.ID}
+				store.EXPECT().GetUser(gomock.Any(), operator.Username).Times(1).Return(operator, nil)
+				store.EXPECT().GetTransfer(gomock.Any(), transfer.ID).Times(1).Return(transfer, nil)
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(reversal, nil)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+
+				// the nullable fields are plain values instead of the sql null types
+				var got struct {
+					Transfer  map[string]interface{} `json:"transfer"`
+					FromEntry map[string]interface{} `json:"from_entry"`
+					ToEntry   map[string]interface{} `json:"to_entry"`
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				assert.NoError(t, err)
+				assert.Equal(t, reversal.Transfer.ReversedAt.Time.Format(time.RFC3339), got.Transfer["ReversedAt"])
+				assert.Equal(t, float64(transfer.ID), got.FromEntry["TransferID"])
+				assert.Equal(t, float64(transfer.ToAccountID), got.FromEntry["CounterpartyAccountID"])
+				assert.Equal(t, float64(transfer.ID), got.ToEntry["TransferID"])
+				assert.Equal(t, float64(transfer.FromAccountID), got.ToEntry["CounterpartyAccountID"])
+			},
+		},
+		{
+			"Forbidden customer",
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, customer.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), customer.Username).Times(1).Return(customer, nil)
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusForbidden, recorder.Code)
+				checkErrorCode(t, recorder.Body, errCodeForbidden)
+			},
+		},
+		{
+			"Unauthorized user not found",
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, operator.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), operator.Username).Times(1).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			"Unauthorized no authorization",
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			"NotFound",
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, operator.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), operator.Username).Times(1).Return(operator, nil)
+				store.EXPECT().GetTransfer(gomock.Any(), transfer.ID).Times(1).Return(db.Transfer{}, sql.ErrNoRows)
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, recorder.Code)
+				checkErrorCode(t, recorder.Body, errCodeTransferNotFound)
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/operator/transfers/%d/reversal", transfer.ID)
+			request, err := http.NewRequest(http.MethodPost, url, &bytes.Buffer{})
+			assert.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestCreatePendingTransferAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+	account1 := randomAccount(user1.Username)
+	account1.Currency = "USD"
+	account2 := randomAccount(user2.Username)
+	account2.Currency = "USD"
+	otherCurrencyAccount := randomAccount(user2.Username)
+	otherCurrencyAccount.Currency = "TWD"
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			"OK",
+			gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          1050,
+				"currency":        "USD",
+			},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(1).Return(account2, nil)
+				arg := db.TransferTxParams{
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+					Amount:        1050,
+				}
+				store.EXPECT().AuthorizeTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.Transfer{
+					ID:            1,
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+					Amount:        1050,
+					ToAmount:      1050,
+					Status:        db.TransferStatusPending,
+				}, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+
+				var got transferResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				assert.NoError(t, err)
+				assert.Equal(t, db.TransferStatusPending, got.Status)
+				assert.Equal(t, "$10.50", got.FormattedAmount)
+			},
+		},
+		{
+			"Forbidden from account not owned by user",
+			gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          1050,
+				"currency":        "USD",
+			},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(account1, nil)
+				store.EXPECT().AuthorizeTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusForbidden, recorder.Code)
+				checkErrorCode(t, recorder.Body, errCodeForbidden)
+			},
+		},
+		{
+			"BadRequest to account currency mismatch",
+			gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   otherCurrencyAccount.ID,
+				"amount":          1050,
+				"currency":        "USD",
+			},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), otherCurrencyAccount.ID).Times(1).Return(otherCurrencyAccount, nil)
+				store.EXPECT().AuthorizeTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+				checkErrorCode(t, recorder.Body, errCodeCurrencyMismatch)
+			},
+		},
+		{
+			"InternalError",
+			gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          1050,
+				"currency":        "USD",
+			},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(1).Return(account2, nil)
+				store.EXPECT().AuthorizeTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.Transfer{}, sql.ErrConnDone)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			assert.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/transfers/pending", bytes.NewReader(data))
+			assert.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListTransferStatusChangesAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account2.ID = account1.ID + 1
+	transfer := db.Transfer{
+		ID:            randomInt(1, 1000),
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        randomMoney(),
+		Status:        db.TransferStatusCompleted,
+	}
+	changes := []db.TransferStatusChange{
+		{ID: 1, TransferID: transfer.ID, Status: db.TransferStatusPending},
+		{ID: 2, TransferID: transfer.ID, Status: db.TransferStatusCompleted},
+	}
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			"OK receiver",
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), transfer.ID).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(1).Return(account2, nil)
+				store.EXPECT().ListTransferStatusChanges(gomock.Any(), transfer.ID).Times(1).Return(changes, nil)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+
+				var got []db.TransferStatusChange
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				assert.NoError(t, err)
+				assert.Equal(t, changes, got)
+			},
+		},
+		{
+			"Forbidden transfer not owned by user",
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), transfer.ID).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(1).Return(account2, nil)
+				store.EXPECT().ListTransferStatusChanges(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusForbidden, recorder.Code)
+				checkErrorCode(t, recorder.Body, errCodeForbidden)
+			},
+		},
+		{
+			"NotFound",
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), transfer.ID).Times(1).Return(db.Transfer{}, sql.ErrNoRows)
+				store.EXPECT().ListTransferStatusChanges(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, recorder.Code)
+				checkErrorCode(t, recorder.Body, errCodeTransferNotFound)
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/transfers/%d/status_changes", transfer.ID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			assert.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestOperatorPendingTransferAPI(t *testing.T) {
+	operator, _ := randomUser(t)
+	operator.Role = db.UserRoleOperator
+	customer, _ := randomUser(t)
+	transfer := db.Transfer{
+		ID:            randomInt(1, 1000),
+		FromAccountID: randomInt(1, 1000),
+		ToAccountID:   randomInt(1001, 2000),
+		Amount:        randomMoney(),
+		Status:        db.TransferStatusPending,
+	}
+	transfer.ToAmount = transfer.Amount
+	settleURL := fmt.Sprintf("/operator/transfers/%d/settlement", transfer.ID)
+	failURL := fmt.Sprintf("/operator/transfers/%d/failure", transfer.ID)
+
+	testCases := []struct {
+		name          string
+		url           string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			"OK settle",
+			settleURL,
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, operator.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				settled := transfer
+				settled.Status = db.TransferStatusCompleted
+				store.EXPECT().GetUser(gomock.Any(), operator.Username).Times(1).Return(operator, nil)
+				store.EXPECT().SettleTransferTx(gomock.Any(), transfer.ID).Times(1).
+					Return(db.TransferTxResult{Transfer: settled}, nil)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+
+				var got transferTxResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				assert.NoError(t, err)
+				assert.Equal(t, db.TransferStatusCompleted, got.Transfer.Status)
+			},
+		},
+		{
+			"InsufficientFunds settle",
+			settleURL,
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, operator.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), operator.Username).Times(1).Return(operator, nil)
+				store.EXPECT().SettleTransferTx(gomock.Any(), transfer.ID).Times(1).
+					Return(db.TransferTxResult{}, db.ErrInsufficientFunds)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				checkErrorCode(t, recorder.Body, errCodeInsufficientFunds)
+			},
+		},
+		{
+			"Conflict settle completed transfer",
+			settleURL,
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, operator.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), operator.Username).Times(1).Return(operator, nil)
+				store.EXPECT().SettleTransferTx(gomock.Any(), transfer.ID).Times(1).
+					Return(db.TransferTxResult{}, fmt.Errorf("%w: completed to completed", db.ErrInvalidTransferTransition))
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusConflict, recorder.Code)
+				checkErrorCode(t, recorder.Body, errCodeInvalidTransferStatus)
+			},
+		},
+		{
+			"NotFound settle",
+			settleURL,
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, operator.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), operator.Username).Times(1).Return(operator, nil)
+				store.EXPECT().SettleTransferTx(gomock.Any(), transfer.ID).Times(1).
+					Return(db.TransferTxResult{}, sql.ErrNoRows)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, recorder.Code)
+				checkErrorCode(t, recorder.Body, errCodeTransferNotFound)
+			},
+		},
+		{
+			"Forbidden settle customer",
+			settleURL,
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, customer.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), customer.Username).Times(1).Return(customer, nil)
+				store.EXPECT().SettleTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusForbidden, recorder.Code)
+				checkErrorCode(t, recorder.Body, errCodeForbidden)
+			},
+		},
+		{
+			"OK fail",
+			failURL,
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, operator.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				failed := transfer
+				failed.Status = db.TransferStatusFailed
+				store.EXPECT().GetUser(gomock.Any(), operator.Username).Times(1).Return(operator, nil)
+				failed.Amount = 1050
+				failed.ToAmount = 1050
+				store.EXPECT().FailTransferTx(gomock.Any(), transfer.ID).Times(1).Return(failed, nil)
+				store.EXPECT().GetAccount(gomock.Any(), transfer.FromAccountID).Times(1).
+					Return(db.Account{ID: transfer.FromAccountID, Currency: "USD"}, nil)
+				store.EXPECT().GetAccount(gomock.Any(), transfer.ToAccountID).Times(1).
+					Return(db.Account{ID: transfer.ToAccountID, Currency: "USD"}, nil)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+
+				var got transferResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				assert.NoError(t, err)
+				assert.Equal(t, db.TransferStatusFailed, got.Status)
+				assert.Equal(t, "$10.50", got.FormattedAmount)
+				assert.Equal(t, "$10.50", got.FormattedToAmount)
+			},
+		},
+		{
+			"Forbidden fail customer",
+			failURL,
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, customer.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), customer.Username).Times(1).Return(customer, nil)
+				store.EXPECT().FailTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPost, tc.url, &bytes.Buffer{})
+			assert.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListTransfersAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	testCases := []struct {
+		name          string
+		queryParam    gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			"OK all directions",
+			gin.H{"account_id": account.ID, "page_id": 1, "page_size": 5},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				arg := db.ListTransfersByAccountParams{
+					FromAccountID: account.ID,
+					ToAccountID:   account.ID,
+					Limit:         5,
+					Offset:        0,
+				}
+				store.EXPECT().ListTransfersByAccount(gomock.Any(), arg).Times(1).Return(nil, nil)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			"OK incoming transfers",
+			gin.H{"account_id": account.ID, "direction": "in", "page_id": 2, "page_size": 5},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				arg := db.ListTransfersByAccountParams{
+					ToAccountID: account.ID,
+					Limit:       5,
+					Offset:      5,
+				}
+				store.EXPECT().ListTransfersByAccount(gomock.Any(), arg).Times(1).Return(nil, nil)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			"OK outgoing transfers",
+			gin.H{"account_id": account.ID, "direction": "out", "page_id": 1, "page_size": 5},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				arg := db.ListTransfersByAccountParams{
+					FromAccountID: account.ID,
+					Limit:         5,
+					Offset:        0,
+				}
+				store.EXPECT().ListTransfersByAccount(gomock.Any(), arg).Times(1).Return(nil, nil)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			"OK cursor",
+			gin.H{"account_id": account.ID, "direction": "in", "cursor": encodeCursor(42), "page_size": 5},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				arg := db.ListTransfersByAccountAfterParams{
+					ToAccountID: account.ID,
+					Cursor:      42,
+					Limit:       6,
+				}
+				store.EXPECT().ListTransfersByAccountAfter(gomock.Any(), arg).Times(1).Return(nil, nil)
+				store.EXPECT().ListTransfersByAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			"BadRequest invalid direction",
+			gin.H{"account_id": account.ID, "direction": "sideways", "page_id": 1, "page_size": 5},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListTransfersByAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			"BadRequest missing account id",
+			gin.H{"page_id": 1, "page_size": 5},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListTransfersByAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			"Forbidden account not owned by user",
+			gin.H{"account_id": account.ID, "page_id": 1, "page_size": 5},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				store.EXPECT().ListTransfersByAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			"NotFound account not found",
+			gin.H{"account_id": account.ID, "page_id": 1, "page_size": 5},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().ListTransfersByAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			"InternalError",
+			gin.H{"account_id": account.ID, "page_id": 1, "page_size": 5},
+			func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				store.EXPECT().ListTransfersByAccount(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			q := url.Values{}
+			for k, v := range tc.queryParam {
+				q.Add(k, fmt.Sprint(v))
+			}
+			url := fmt.Sprintf("/transfers?%s", q.Encode())
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			assert.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func checkErrorCode(t *testing.T, body *bytes.Buffer, code string) {
+	var got struct {
+		Code string `json:"code"`
+	}
+	err := json.Unmarshal(body.Bytes(), &got)
+	assert.NoError(t, err)
+	assert.Equal(t, code, got.Code)
+}
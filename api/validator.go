@@ -0,0 +1,44 @@
+package api
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/peienxie/go-bank/currency"
+	"github.com/peienxie/go-bank/schedule"
+)
+
+// validCurrency validates the field is a currency code supported by the registry
+func validCurrency(currencies *currency.Registry) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		if code, ok := fl.Field().Interface().(string); ok {
+			return currencies.IsSupported(code)
+		}
+		return false
+	}
+}
+
+// validSchedule validates the field is a schedule spec supported by the scheduler
+func validSchedule(fl validator.FieldLevel) bool {
+	if spec, ok := fl.Field().Interface().(string); ok {
+		_, err := schedule.Parse(spec)
+		return err == nil
+	}
+	return false
+}
+
+// fieldName returns the name of the field in the request, so the validation
+// errors refer to the fields the client knows instead of the Go struct fields
+func fieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "uri", "form"} {
+		name := strings.SplitN(field.Tag.Get(key), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
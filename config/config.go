@@ -4,15 +4,34 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 // Config stores all configuration of application
 type Config struct {
-	DBDriver      string `mapstructure:"DB_DRIVER"`
-	DBSource      string `mapstructure:"DB_SOURCE"`
-	ServerAddress string `mapstructure:"SERVER_ADDRESS"`
+	DBDriver             string        `mapstructure:"DB_DRIVER"`
+	DBSource             string        `mapstructure:"DB_SOURCE"`
+	ServerAddress        string        `mapstructure:"SERVER_ADDRESS"`
+	ServerReadTimeout    time.Duration `mapstructure:"SERVER_READ_TIMEOUT"`
+	ServerWriteTimeout   time.Duration `mapstructure:"SERVER_WRITE_TIMEOUT"`
+	ServerIdleTimeout    time.Duration `mapstructure:"SERVER_IDLE_TIMEOUT"`
+	ShutdownTimeout      time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"`
+	LogLevel             string        `mapstructure:"LOG_LEVEL"`
+	LogFormat            string        `mapstructure:"LOG_FORMAT"`
+	TraceExporter        string        `mapstructure:"TRACE_EXPORTER"`
+	TraceOTLPEndpoint    string        `mapstructure:"TRACE_OTLP_ENDPOINT"`
+	TokenType            string        `mapstructure:"TOKEN_TYPE"`
+	TokenSymmetricKey    string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	AccessTokenDuration  time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	ExchangeRateSource   string        `mapstructure:"EXCHANGE_RATE_SOURCE"`
+	ExchangeRateFile     string        `mapstructure:"EXCHANGE_RATE_FILE"`
+	ExchangeRateCacheTTL time.Duration `mapstructure:"EXCHANGE_RATE_CACHE_TTL"`
+	HoldDuration         time.Duration `mapstructure:"HOLD_DURATION"`
+	HoldExpiryInterval   time.Duration `mapstructure:"HOLD_EXPIRY_INTERVAL"`
+	SchedulerInterval    time.Duration `mapstructure:"SCHEDULER_INTERVAL"`
 }
 
 // LoadConfig loads configuration from environment variables
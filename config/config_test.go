@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/peienxie/go-bank/config"
 	"github.com/stretchr/testify/assert"
@@ -15,6 +16,24 @@ func createDefaultEnvFile(t *testing.T) {
 	envs["DB_SOURCE"] = "default_source"
 	envs["DB_DRIVER"] = "default_driver"
 	envs["SERVER_ADDRESS"] = "default_address"
+	envs["SERVER_READ_TIMEOUT"] = "5s"
+	envs["SERVER_WRITE_TIMEOUT"] = "10s"
+	envs["SERVER_IDLE_TIMEOUT"] = "2m"
+	envs["SHUTDOWN_TIMEOUT"] = "30s"
+	envs["LOG_LEVEL"] = "default_level"
+	envs["LOG_FORMAT"] = "default_format"
+	envs["TRACE_EXPORTER"] = "default_exporter"
+	envs["TRACE_OTLP_ENDPOINT"] = "default_endpoint"
+	envs["TOKEN_TYPE"] = "default_type"
+	envs["TOKEN_SYMMETRIC_KEY"] = "default_key"
+	envs["ACCESS_TOKEN_DURATION"] = "15m"
+	envs["REFRESH_TOKEN_DURATION"] = "24h"
+	envs["EXCHANGE_RATE_SOURCE"] = "default_rate_source"
+	envs["EXCHANGE_RATE_FILE"] = "default_rate_file"
+	envs["EXCHANGE_RATE_CACHE_TTL"] = "1m"
+	envs["HOLD_DURATION"] = "168h"
+	envs["HOLD_EXPIRY_INTERVAL"] = "1m"
+	envs["SCHEDULER_INTERVAL"] = "1m"
 
 	var envString string
 	for k, v := range envs {
@@ -40,6 +59,24 @@ func TestLoadDefaultConfig(t *testing.T) {
 	assert.Equal(t, "default_driver", config.DBDriver)
 	assert.Equal(t, "default_source", config.DBSource)
 	assert.Equal(t, "default_address", config.ServerAddress)
+	assert.Equal(t, 5*time.Second, config.ServerReadTimeout)
+	assert.Equal(t, 10*time.Second, config.ServerWriteTimeout)
+	assert.Equal(t, 2*time.Minute, config.ServerIdleTimeout)
+	assert.Equal(t, 30*time.Second, config.ShutdownTimeout)
+	assert.Equal(t, "default_level", config.LogLevel)
+	assert.Equal(t, "default_format", config.LogFormat)
+	assert.Equal(t, "default_exporter", config.TraceExporter)
+	assert.Equal(t, "default_endpoint", config.TraceOTLPEndpoint)
+	assert.Equal(t, "default_type", config.TokenType)
+	assert.Equal(t, "default_key", config.TokenSymmetricKey)
+	assert.Equal(t, 15*time.Minute, config.AccessTokenDuration)
+	assert.Equal(t, 24*time.Hour, config.RefreshTokenDuration)
+	assert.Equal(t, "default_rate_source", config.ExchangeRateSource)
+	assert.Equal(t, "default_rate_file", config.ExchangeRateFile)
+	assert.Equal(t, time.Minute, config.ExchangeRateCacheTTL)
+	assert.Equal(t, 168*time.Hour, config.HoldDuration)
+	assert.Equal(t, time.Minute, config.HoldExpiryInterval)
+	assert.Equal(t, time.Minute, config.SchedulerInterval)
 
 	cleanupEnvFile(t)
 }
@@ -48,6 +85,24 @@ func TestOverrideConfigByEnvironmentVariables(t *testing.T) {
 	os.Setenv("GOBANK_DB_SOURCE", "mysource")
 	os.Setenv("GOBANK_DB_DRIVER", "mydriver")
 	os.Setenv("GOBANK_SERVER_ADDRESS", "localhost:8080")
+	os.Setenv("GOBANK_SERVER_READ_TIMEOUT", "1s")
+	os.Setenv("GOBANK_SERVER_WRITE_TIMEOUT", "2s")
+	os.Setenv("GOBANK_SERVER_IDLE_TIMEOUT", "3s")
+	os.Setenv("GOBANK_SHUTDOWN_TIMEOUT", "4s")
+	os.Setenv("GOBANK_LOG_LEVEL", "debug")
+	os.Setenv("GOBANK_LOG_FORMAT", "console")
+	os.Setenv("GOBANK_TRACE_EXPORTER", "otlp")
+	os.Setenv("GOBANK_TRACE_OTLP_ENDPOINT", "http://collector:4318/v1/traces")
+	os.Setenv("GOBANK_TOKEN_TYPE", "mytype")
+	os.Setenv("GOBANK_TOKEN_SYMMETRIC_KEY", "mykey")
+	os.Setenv("GOBANK_ACCESS_TOKEN_DURATION", "1h")
+	os.Setenv("GOBANK_REFRESH_TOKEN_DURATION", "48h")
+	os.Setenv("GOBANK_EXCHANGE_RATE_SOURCE", "file")
+	os.Setenv("GOBANK_EXCHANGE_RATE_FILE", "rates.csv")
+	os.Setenv("GOBANK_EXCHANGE_RATE_CACHE_TTL", "30s")
+	os.Setenv("GOBANK_HOLD_DURATION", "24h")
+	os.Setenv("GOBANK_HOLD_EXPIRY_INTERVAL", "10s")
+	os.Setenv("GOBANK_SCHEDULER_INTERVAL", "5s")
 
 	config, err := config.LoadConfig(".")
 	assert.NoError(t, err)
@@ -56,4 +111,22 @@ func TestOverrideConfigByEnvironmentVariables(t *testing.T) {
 	assert.Equal(t, "mydriver", config.DBDriver)
 	assert.Equal(t, "mysource", config.DBSource)
 	assert.Equal(t, "localhost:8080", config.ServerAddress)
+	assert.Equal(t, time.Second, config.ServerReadTimeout)
+	assert.Equal(t, 2*time.Second, config.ServerWriteTimeout)
+	assert.Equal(t, 3*time.Second, config.ServerIdleTimeout)
+	assert.Equal(t, 4*time.Second, config.ShutdownTimeout)
+	assert.Equal(t, "debug", config.LogLevel)
+	assert.Equal(t, "console", config.LogFormat)
+	assert.Equal(t, "otlp", config.TraceExporter)
+	assert.Equal(t, "http://collector:4318/v1/traces", config.TraceOTLPEndpoint)
+	assert.Equal(t, "mytype", config.TokenType)
+	assert.Equal(t, "mykey", config.TokenSymmetricKey)
+	assert.Equal(t, time.Hour, config.AccessTokenDuration)
+	assert.Equal(t, 48*time.Hour, config.RefreshTokenDuration)
+	assert.Equal(t, "file", config.ExchangeRateSource)
+	assert.Equal(t, "rates.csv", config.ExchangeRateFile)
+	assert.Equal(t, 30*time.Second, config.ExchangeRateCacheTTL)
+	assert.Equal(t, 24*time.Hour, config.HoldDuration)
+	assert.Equal(t, 10*time.Second, config.HoldExpiryInterval)
+	assert.Equal(t, 5*time.Second, config.SchedulerInterval)
 }
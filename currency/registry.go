@@ -0,0 +1,54 @@
+package currency
+
+import "fmt"
+
+// Currency describes an ISO 4217 currency
+type Currency struct {
+	Code     string
+	Exponent int32
+	Symbol   string
+}
+
+// FormatAmount renders an amount in minor units with the currency's decimal places
+func (c Currency) FormatAmount(amount int64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	if c.Exponent <= 0 {
+		return fmt.Sprintf("%s%s%d", sign, c.Symbol, amount)
+	}
+
+	unit := int64(1)
+	for i := int32(0); i < c.Exponent; i++ {
+		unit *= 10
+	}
+	return fmt.Sprintf("%s%s%d.%0*d", sign, c.Symbol, amount/unit, c.Exponent, amount%unit)
+}
+
+// Registry holds the currencies supported by the bank
+type Registry struct {
+	currencies map[string]Currency
+}
+
+// NewRegistry creates a registry of the given currencies
+func NewRegistry(currencies ...Currency) *Registry {
+	r := &Registry{currencies: make(map[string]Currency, len(currencies))}
+	for _, c := range currencies {
+		r.currencies[c.Code] = c
+	}
+	return r
+}
+
+// Get returns the currency of the code
+func (r *Registry) Get(code string) (Currency, bool) {
+	c, ok := r.currencies[code]
+	return c, ok
+}
+
+// IsSupported reports whether the currency code is in the registry
+func (r *Registry) IsSupported(code string) bool {
+	_, ok := r.currencies[code]
+	return ok
+}
@@ -0,0 +1,43 @@
+package currency
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	usd := Currency{Code: "USD", Exponent: 2, Symbol: "$"}
+	jpy := Currency{Code: "JPY", Exponent: 0, Symbol: "¥"}
+	registry := NewRegistry(usd, jpy)
+
+	got, ok := registry.Get("USD")
+	assert.True(t, ok)
+	assert.Equal(t, usd, got)
+
+	assert.True(t, registry.IsSupported("JPY"))
+	assert.False(t, registry.IsSupported("EUR"))
+	assert.False(t, registry.IsSupported("usd"))
+
+	_, ok = registry.Get("EUR")
+	assert.False(t, ok)
+}
+
+func TestFormatAmount(t *testing.T) {
+	testCases := []struct {
+		currency Currency
+		amount   int64
+		expected string
+	}{
+		{Currency{Code: "USD", Exponent: 2, Symbol: "$"}, 1234, "$12.34"},
+		{Currency{Code: "USD", Exponent: 2, Symbol: "$"}, 5, "$0.05"},
+		{Currency{Code: "USD", Exponent: 2, Symbol: "$"}, -1999, "-$19.99"},
+		{Currency{Code: "JPY", Exponent: 0, Symbol: "¥"}, 1234, "¥1234"},
+		{Currency{Code: "BHD", Exponent: 3, Symbol: "BD"}, 1500, "BD1.500"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expected, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.currency.FormatAmount(tc.amount))
+		})
+	}
+}
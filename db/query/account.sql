@@ -11,18 +11,42 @@ INSERT INTO accounts (
 SELECT * FROM accounts
 WHERE id = $1 LIMIT 1;
 
+-- name: GetAccountForUpdate :one
+SELECT * FROM accounts
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
 -- name: ListAccounts :many
 SELECT * FROM accounts
 ORDER BY id
 LIMIT $1
 OFFSET $2;
 
+-- name: ListAccountsByUsername :many
+SELECT * FROM accounts
+WHERE username = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3;
+
+-- name: ListAccountsByUsernameAfter :many
+SELECT * FROM accounts
+WHERE username = sqlc.arg(username) AND id > sqlc.arg(cursor)
+ORDER BY id
+LIMIT sqlc.arg('limit');
+
 -- name: UpdateAccountBalance :one
 UPDATE accounts
 SET balance = $2
 WHERE id = $1
 RETURNING *;
 
+-- name: UpdateAccountOverdraftLimit :one
+UPDATE accounts
+SET overdraft_limit = $2
+WHERE id = $1
+RETURNING *;
+
 -- name: AddAccountBalance :one
 UPDATE accounts
 SET balance = balance + sqlc.arg(amount)
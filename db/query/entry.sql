@@ -1,9 +1,12 @@
 -- name: CreateEntry :one
 INSERT INTO entries (
   account_id,
-  amount
+  amount,
+  transfer_id,
+  type,
+  balance_after
 ) VALUES (
-  $1, $2
+  $1, $2, $3, $4, $5
 ) RETURNING *;
 
 -- name: GetEntry :one
@@ -16,6 +19,27 @@ ORDER BY id
 LIMIT $1
 OFFSET $2;
 
+-- name: ListAccountEntries :many
+SELECT
+  entries.id, entries.account_id, entries.amount, entries.created_at, entries.transfer_id, entries.type, entries.balance_after,
+  (CASE WHEN transfers.from_account_id = entries.account_id THEN transfers.to_account_id ELSE transfers.from_account_id END) AS counterparty_account_id
+FROM entries
+LEFT JOIN transfers ON transfers.id = entries.transfer_id
+WHERE entries.account_id = sqlc.arg(account_id) AND entries.created_at >= sqlc.arg(from_time) AND entries.created_at < sqlc.arg(to_time)
+ORDER BY entries.id
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: ListAccountEntriesAfter :many
+SELECT
+  entries.id, entries.account_id, entries.amount, entries.created_at, entries.transfer_id, entries.type, entries.balance_after,
+  (CASE WHEN transfers.from_account_id = entries.account_id THEN transfers.to_account_id ELSE transfers.from_account_id END) AS counterparty_account_id
+FROM entries
+LEFT JOIN transfers ON transfers.id = entries.transfer_id
+WHERE entries.account_id = sqlc.arg(account_id) AND entries.created_at >= sqlc.arg(from_time) AND entries.created_at < sqlc.arg(to_time) AND entries.id > sqlc.arg(cursor)
+ORDER BY entries.id
+LIMIT sqlc.arg('limit');
+
 -- name: DeleteEntry :exec
 DELETE FROM entries WHERE id = $1;
 
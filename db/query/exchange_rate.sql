@@ -0,0 +1,15 @@
+-- name: CreateExchangeRate :one
+INSERT INTO exchange_rates (
+  from_currency,
+  to_currency,
+  rate,
+  effective_at
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetExchangeRate :one
+SELECT * FROM exchange_rates
+WHERE from_currency = $1 AND to_currency = $2 AND effective_at <= now()
+ORDER BY effective_at DESC
+LIMIT 1;
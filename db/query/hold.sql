@@ -0,0 +1,39 @@
+-- name: CreateHold :one
+INSERT INTO holds (
+  account_id,
+  to_account_id,
+  amount,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetHold :one
+SELECT * FROM holds
+WHERE id = $1 LIMIT 1;
+
+-- name: GetHoldForUpdate :one
+SELECT * FROM holds
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: GetAccountHeldAmount :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS held_amount FROM holds
+WHERE account_id = $1 AND status = 'active' AND expires_at > now();
+
+-- name: CaptureHold :one
+UPDATE holds
+SET status = 'captured', transfer_id = $2
+WHERE id = $1 AND expires_at > now()
+RETURNING *;
+
+-- name: UpdateHoldStatus :one
+UPDATE holds
+SET status = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: ExpireHolds :execrows
+UPDATE holds
+SET status = 'expired'
+WHERE status = 'active' AND expires_at <= now();
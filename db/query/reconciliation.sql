@@ -0,0 +1,32 @@
+-- name: ListBalanceMismatches :many
+SELECT accounts.id AS account_id, accounts.balance, COALESCE(SUM(entries.amount), 0)::bigint AS entries_sum
+FROM accounts
+LEFT JOIN entries ON entries.account_id = accounts.id
+GROUP BY accounts.id
+HAVING accounts.balance <> COALESCE(SUM(entries.amount), 0)
+ORDER BY accounts.id;
+
+-- name: ListUnmatchedTransfers :many
+SELECT transfer_id, debit_entries, credit_entries FROM (
+  SELECT
+    transfers.id AS transfer_id,
+    (
+      SELECT COUNT(*) FROM entries
+      WHERE entries.transfer_id = transfers.id
+        AND entries.type = 'transfer'
+        AND entries.account_id = transfers.from_account_id
+        AND entries.amount = -transfers.amount
+    ) AS debit_entries,
+    (
+      SELECT COUNT(*) FROM entries
+      WHERE entries.transfer_id = transfers.id
+        AND entries.type = 'transfer'
+        AND entries.account_id = transfers.to_account_id
+        AND entries.amount = transfers.to_amount
+    ) AS credit_entries
+  FROM transfers
+  -- pending and failed transfers have no entries yet
+  WHERE transfers.status IN ('completed', 'reversed')
+) AS transfer_entries
+WHERE debit_entries <> 1 OR credit_entries <> 1
+ORDER BY transfer_id;
@@ -0,0 +1,58 @@
+-- name: CreateScheduledTransfer :one
+INSERT INTO scheduled_transfers (
+  username,
+  from_account_id,
+  to_account_id,
+  amount,
+  currency,
+  schedule,
+  start_at,
+  next_run_at,
+  end_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING *;
+
+-- name: GetScheduledTransfer :one
+SELECT * FROM scheduled_transfers
+WHERE id = $1 LIMIT 1;
+
+-- name: GetDueScheduledTransferForUpdate :one
+SELECT * FROM scheduled_transfers
+WHERE next_run_at <= now()
+ORDER BY next_run_at
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: ListScheduledTransfersByUsername :many
+SELECT * FROM scheduled_transfers
+WHERE username = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3;
+
+-- name: ListScheduledTransfersByUsernameAfter :many
+SELECT * FROM scheduled_transfers
+WHERE username = sqlc.arg(username) AND id > sqlc.arg(cursor)
+ORDER BY id
+LIMIT sqlc.arg('limit');
+
+-- name: UpdateScheduledTransfer :one
+UPDATE scheduled_transfers
+SET
+  amount = $2,
+  -- a changed schedule starts over from the next run
+  start_at = CASE WHEN schedule = $3 THEN start_at ELSE COALESCE(next_run_at, start_at) END,
+  schedule = $3,
+  end_at = $4
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateScheduledTransferNextRun :one
+UPDATE scheduled_transfers
+SET next_run_at = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteScheduledTransfer :exec
+DELETE FROM scheduled_transfers WHERE id = $1;
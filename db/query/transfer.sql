@@ -2,21 +2,53 @@
 INSERT INTO transfers (
   from_account_id,
   to_account_id,
-  amount
+  amount,
+  to_amount,
+  exchange_rate
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4, $5
 ) RETURNING *;
 
 -- name: GetTransfer :one
 SELECT * FROM transfers
 WHERE id = $1 LIMIT 1;
 
+-- name: GetTransferForUpdate :one
+SELECT * FROM transfers
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
 -- name: ListTransfers :many
 SELECT * FROM transfers
 ORDER BY id
 LIMIT $1
 OFFSET $2;
 
+-- name: ListTransfersByAccount :many
+SELECT * FROM transfers
+WHERE from_account_id = sqlc.arg(from_account_id) OR to_account_id = sqlc.arg(to_account_id)
+ORDER BY id
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: ListTransfersByAccountAfter :many
+SELECT * FROM transfers
+WHERE (from_account_id = sqlc.arg(from_account_id) OR to_account_id = sqlc.arg(to_account_id)) AND id > sqlc.arg(cursor)
+ORDER BY id
+LIMIT sqlc.arg('limit');
+
+-- name: UpdateTransferReversal :one
+UPDATE transfers
+SET reversed_amount = $2, reversed_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateTransferStatus :one
+UPDATE transfers
+SET status = $2
+WHERE id = $1
+RETURNING *;
+
 -- name: DeleteTransfer :exec
 DELETE FROM transfers WHERE id = $1;
 
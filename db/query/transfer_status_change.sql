@@ -0,0 +1,12 @@
+-- name: CreateTransferStatusChange :one
+INSERT INTO transfer_status_changes (
+  transfer_id,
+  status
+) VALUES (
+  $1, $2
+) RETURNING *;
+
+-- name: ListTransferStatusChanges :many
+SELECT * FROM transfer_status_changes
+WHERE transfer_id = $1
+ORDER BY id;
@@ -0,0 +1,16 @@
+CREATE TABLE "users" (
+  "username" varchar PRIMARY KEY,
+  "hashed_password" varchar NOT NULL,
+  "full_name" varchar NOT NULL,
+  "email" varchar UNIQUE NOT NULL,
+  "password_changed_at" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+-- the owners of existing accounts become users who can't log in until their password is reset,
+-- the empty hash never matches a password and the email is under the reserved .invalid domain
+INSERT INTO "users" ("username", "hashed_password", "full_name", "email")
+SELECT DISTINCT "username", '', "username", "username" || '@users.invalid'
+FROM "accounts";
+
+ALTER TABLE "accounts" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
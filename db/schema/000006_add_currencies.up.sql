@@ -0,0 +1,13 @@
+CREATE TABLE "currencies" (
+  "code" varchar PRIMARY KEY,
+  "exponent" int NOT NULL,
+  "symbol" varchar NOT NULL
+);
+
+INSERT INTO "currencies" ("code", "exponent", "symbol") VALUES
+  ('USD', 2, '$'),
+  ('TWD', 2, 'NT$'),
+  ('EUR', 2, '€'),
+  ('JPY', 0, '¥');
+
+ALTER TABLE "accounts" ADD FOREIGN KEY ("currency") REFERENCES "currencies" ("code");
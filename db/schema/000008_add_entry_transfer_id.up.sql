@@ -0,0 +1,28 @@
+CREATE TYPE "entry_type" AS ENUM (
+  'transfer',
+  'deposit',
+  'withdrawal',
+  'fee',
+  'reversal'
+);
+
+ALTER TABLE "entries" ADD COLUMN "transfer_id" bigint;
+
+ALTER TABLE "entries" ADD COLUMN "type" entry_type;
+
+ALTER TABLE "entries" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "entries" ("transfer_id");
+
+-- link the existing entries created in the same transaction of a transfer
+UPDATE "entries" SET "transfer_id" = "transfers"."id", "type" = 'transfer'
+FROM "transfers"
+WHERE "entries"."created_at" = "transfers"."created_at" AND (
+  ("entries"."account_id" = "transfers"."from_account_id" AND "entries"."amount" = -"transfers"."amount") OR
+  ("entries"."account_id" = "transfers"."to_account_id" AND "entries"."amount" = "transfers"."to_amount")
+);
+
+UPDATE "entries" SET "type" = (CASE WHEN "amount" >= 0 THEN 'deposit' ELSE 'withdrawal' END)::entry_type
+WHERE "type" IS NULL;
+
+ALTER TABLE "entries" ALTER COLUMN "type" SET NOT NULL;
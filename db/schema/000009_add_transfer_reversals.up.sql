@@ -0,0 +1,13 @@
+ALTER TABLE "transfers" ADD COLUMN "reversed_amount" bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE "transfers" ADD COLUMN "reversed_at" timestamptz;
+
+ALTER TABLE "transfers" ADD CONSTRAINT "reversed_amount_within_amount" CHECK ("reversed_amount" >= 0 AND "reversed_amount" <= "amount");
+
+CREATE TYPE "user_role" AS ENUM (
+  'customer',
+  'operator'
+);
+
+-- operators are support staff allowed to reverse the transfers of other users
+ALTER TABLE "users" ADD COLUMN "role" user_role NOT NULL DEFAULT 'customer';
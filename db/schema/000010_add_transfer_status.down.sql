@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS "transfer_status_changes";
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "status";
+
+DROP TYPE IF EXISTS transfer_status;
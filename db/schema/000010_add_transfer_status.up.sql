@@ -0,0 +1,30 @@
+CREATE TYPE "transfer_status" AS ENUM (
+  'pending',
+  'completed',
+  'failed',
+  'reversed'
+);
+
+-- the existing transfers were committed along with their entries
+ALTER TABLE "transfers" ADD COLUMN "status" transfer_status NOT NULL DEFAULT 'completed';
+
+UPDATE "transfers" SET "status" = 'reversed' WHERE "reversed_at" IS NOT NULL;
+
+ALTER TABLE "transfers" ALTER COLUMN "status" SET DEFAULT 'pending';
+
+CREATE TABLE "transfer_status_changes" (
+  "id" bigserial PRIMARY KEY,
+  "transfer_id" bigint NOT NULL,
+  "status" transfer_status NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "transfer_status_changes" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "transfer_status_changes" ("transfer_id");
+
+INSERT INTO "transfer_status_changes" ("transfer_id", "status", "created_at")
+SELECT "id", 'completed', "created_at" FROM "transfers";
+
+INSERT INTO "transfer_status_changes" ("transfer_id", "status", "created_at")
+SELECT "id", 'reversed', "reversed_at" FROM "transfers" WHERE "reversed_at" IS NOT NULL;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS "scheduled_transfer_runs";
+
+DROP TYPE IF EXISTS scheduled_transfer_run_status;
+
+DROP TABLE IF EXISTS "scheduled_transfers";
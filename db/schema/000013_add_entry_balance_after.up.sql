@@ -0,0 +1,17 @@
+ALTER TABLE "entries" ADD COLUMN "balance_after" bigint;
+
+-- the balance after each existing entry is worked back from the current balance of the account
+UPDATE "entries" SET "balance_after" = "balances"."balance_after"
+FROM (
+  SELECT
+    "entries"."id",
+    "accounts"."balance" - COALESCE(SUM("entries"."amount") OVER (
+      PARTITION BY "entries"."account_id" ORDER BY "entries"."id" DESC
+      ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING
+    ), 0) AS "balance_after"
+  FROM "entries"
+  JOIN "accounts" ON "accounts"."id" = "entries"."account_id"
+) AS "balances"
+WHERE "entries"."id" = "balances"."id";
+
+ALTER TABLE "entries" ALTER COLUMN "balance_after" SET NOT NULL;
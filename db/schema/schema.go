@@ -0,0 +1,39 @@
+// Package schema embeds the db migrations so the server knows the schema version it's built against
+package schema
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+//go:embed *.up.sql
+var migrations embed.FS
+
+// LatestVersion returns the version of the latest migration,
+// which is the version the db is at after all migrations are applied
+func LatestVersion() (int64, error) {
+	files, err := fs.Glob(migrations, "*.up.sql")
+	if err != nil {
+		return 0, err
+	}
+
+	var latest int64
+	for _, file := range files {
+		// migration files are named <version>_<title>.up.sql
+		i := strings.Index(file, "_")
+		if i < 0 {
+			return 0, fmt.Errorf("invalid migration file name %q", file)
+		}
+		version, err := strconv.ParseInt(file[:i], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid migration file name %q: %w", file, err)
+		}
+		if version > latest {
+			latest = version
+		}
+	}
+	return latest, nil
+}
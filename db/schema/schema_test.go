@@ -0,0 +1,13 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatestVersion(t *testing.T) {
+	version, err := LatestVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(13), version)
+}
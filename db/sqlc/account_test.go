@@ -11,8 +11,10 @@ import (
 
 // createRandomAccount generates a random account data for testing
 func createRandomAccount(t *testing.T) Account {
+	user := createRandomUser(t)
+
 	arg := CreateAccountParams{
-		Username: randomUsername(),
+		Username: user.Username,
 		Balance:  randomMoney(),
 		Currency: randomCurrency(),
 	}
@@ -74,6 +76,57 @@ func TestListAaccount(t *testing.T) {
 	assert.GreaterOrEqual(t, len(accounts)-count, total)
 }
 
+// TestListAccountsByUsername makes sure list only the accounts owned by given user
+func TestListAccountsByUsername(t *testing.T) {
+	var lastAccount Account
+	for i := 0; i < 10; i++ {
+		lastAccount = createRandomAccount(t)
+	}
+
+	accounts, err := testQueries.ListAccountsByUsername(
+		context.Background(),
+		ListAccountsByUsernameParams{Username: lastAccount.Username, Limit: 5, Offset: 0},
+	)
+	assert.NoError(t, err)
+	assert.Len(t, accounts, 1)
+
+	for _, account := range accounts {
+		assert.NotEmpty(t, account)
+		assert.Equal(t, lastAccount.Username, account.Username)
+	}
+}
+
+func TestListAccountsByUsernameAfter(t *testing.T) {
+	user := createRandomUser(t)
+
+	var accounts []Account
+	for i := 0; i < 3; i++ {
+		account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+			Username: user.Username,
+			Balance:  randomMoney(),
+			Currency: randomCurrency(),
+		})
+		assert.NoError(t, err)
+		accounts = append(accounts, account)
+	}
+
+	page, err := testQueries.ListAccountsByUsernameAfter(context.Background(), ListAccountsByUsernameAfterParams{
+		Username: user.Username,
+		Cursor:   0,
+		Limit:    2,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, accounts[:2], page)
+
+	page, err = testQueries.ListAccountsByUsernameAfter(context.Background(), ListAccountsByUsernameAfterParams{
+		Username: user.Username,
+		Cursor:   page[len(page)-1].ID,
+		Limit:    2,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, accounts[2:], page)
+}
+
 // TestUpdateAccountBalance makes sure update account amount of balance by given ID
 func TestUpdateAccountBalance(t *testing.T) {
 	account1 := createRandomAccount(t)
@@ -114,6 +167,25 @@ func TestAddAccountBalance(t *testing.T) {
 	assert.Equal(t, account1.CreatedAt, account2.CreatedAt)
 }
 
+// TestUpdateAccountOverdraftLimit makes sure update account overdraft limit by given ID
+func TestUpdateAccountOverdraftLimit(t *testing.T) {
+	account1 := createRandomAccount(t)
+	assert.Zero(t, account1.OverdraftLimit)
+
+	arg := UpdateAccountOverdraftLimitParams{
+		ID:             account1.ID,
+		OverdraftLimit: randomMoney(),
+	}
+
+	account2, err := testQueries.UpdateAccountOverdraftLimit(context.Background(), arg)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, account2)
+
+	assert.Equal(t, account1.ID, account2.ID)
+	assert.Equal(t, account1.Balance, account2.Balance)
+	assert.Equal(t, arg.OverdraftLimit, account2.OverdraftLimit)
+}
+
 // TestDeleteAccount makes sure delete account record by given ID
 func TestDeleteAccount(t *testing.T) {
 	account1 := createRandomAccount(t)
@@ -0,0 +1,33 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCurrency(t *testing.T) {
+	currency, err := testQueries.GetCurrency(context.Background(), "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", currency.Code)
+	assert.Equal(t, int32(2), currency.Exponent)
+	assert.Equal(t, "$", currency.Symbol)
+
+	_, err = testQueries.GetCurrency(context.Background(), "QWE")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestListCurrencies(t *testing.T) {
+	currencies, err := testQueries.ListCurrencies(context.Background())
+	assert.NoError(t, err)
+	assert.NotEmpty(t, currencies)
+
+	codes := make([]string, len(currencies))
+	for i, currency := range currencies {
+		codes[i] = currency.Code
+	}
+	assert.Contains(t, codes, "USD")
+	assert.Contains(t, codes, "TWD")
+}
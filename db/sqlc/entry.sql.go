@@ -5,30 +5,47 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
 const createEntry = `-- name: CreateEntry :one
 INSERT INTO entries (
   account_id,
-  amount
+  amount,
+  transfer_id,
+  type,
+  balance_after
 ) VALUES (
-  $1, $2
-) RETURNING id, account_id, amount, created_at
+  $1, $2, $3, $4, $5
+) RETURNING id, account_id, amount, created_at, transfer_id, type, balance_after
 `
 
 type CreateEntryParams struct {
-	AccountID int64 `db:"account_id"`
-	Amount    int64 `db:"amount"`
+	AccountID    int64         `db:"account_id"`
+	Amount       int64         `db:"amount"`
+	TransferID   sql.NullInt64 `db:"transfer_id"`
+	Type         EntryType     `db:"type"`
+	BalanceAfter int64         `db:"balance_after"`
 }
 
 func (q *Queries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
-	row := q.db.QueryRowContext(ctx, createEntry, arg.AccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createEntry,
+		arg.AccountID,
+		arg.Amount,
+		arg.TransferID,
+		arg.Type,
+		arg.BalanceAfter,
+	)
 	var i Entry
 	err := row.Scan(
 		&i.ID,
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
+		&i.Type,
+		&i.BalanceAfter,
 	)
 	return i, err
 }
@@ -43,7 +60,7 @@ func (q *Queries) DeleteEntry(ctx context.Context, id int64) error {
 }
 
 const getEntry = `-- name: GetEntry :one
-SELECT id, account_id, amount, created_at FROM entries
+SELECT id, account_id, amount, created_at, transfer_id, type, balance_after FROM entries
 WHERE id = $1 LIMIT 1
 `
 
@@ -55,12 +72,152 @@ func (q *Queries) GetEntry(ctx context.Context, id int64) (Entry, error) {
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
+		&i.Type,
+		&i.BalanceAfter,
 	)
 	return i, err
 }
 
+const listAccountEntries = `-- name: ListAccountEntries :many
+SELECT
+  entries.id, entries.account_id, entries.amount, entries.created_at, entries.transfer_id, entries.type, entries.balance_after,
+  (CASE WHEN transfers.from_account_id = entries.account_id THEN transfers.to_account_id ELSE transfers.from_account_id END) AS counterparty_account_id
+FROM entries
+LEFT JOIN transfers ON transfers.id = entries.transfer_id
+WHERE entries.account_id = $1 AND entries.created_at >= $2 AND entries.created_at < $3
+ORDER BY entries.id
+LIMIT $4
+OFFSET $5
+`
+
+type ListAccountEntriesParams struct {
+	AccountID int64     `db:"account_id"`
+	FromTime  time.Time `db:"from_time"`
+	ToTime    time.Time `db:"to_time"`
+	Limit     int32     `db:"limit"`
+	Offset    int32     `db:"offset"`
+}
+
+type ListAccountEntriesRow struct {
+	ID                    int64         `db:"id"`
+	AccountID             int64         `db:"account_id"`
+	Amount                int64         `db:"amount"`
+	CreatedAt             time.Time     `db:"created_at"`
+	TransferID            sql.NullInt64 `db:"transfer_id"`
+	Type                  EntryType     `db:"type"`
+	BalanceAfter          int64         `db:"balance_after"`
+	CounterpartyAccountID sql.NullInt64 `db:"counterparty_account_id"`
+}
+
+func (q *Queries) ListAccountEntries(ctx context.Context, arg ListAccountEntriesParams) ([]ListAccountEntriesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountEntries,
+		arg.AccountID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListAccountEntriesRow{}
+	for rows.Next() {
+		var i ListAccountEntriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.TransferID,
+			&i.Type,
+			&i.BalanceAfter,
+			&i.CounterpartyAccountID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountEntriesAfter = `-- name: ListAccountEntriesAfter :many
+SELECT
+  entries.id, entries.account_id, entries.amount, entries.created_at, entries.transfer_id, entries.type, entries.balance_after,
+  (CASE WHEN transfers.from_account_id = entries.account_id THEN transfers.to_account_id ELSE transfers.from_account_id END) AS counterparty_account_id
+FROM entries
+LEFT JOIN transfers ON transfers.id = entries.transfer_id
+WHERE entries.account_id = $1 AND entries.created_at >= $2 AND entries.created_at < $3 AND entries.id > $4
+ORDER BY entries.id
+LIMIT $5
+`
+
+type ListAccountEntriesAfterParams struct {
+	AccountID int64     `db:"account_id"`
+	FromTime  time.Time `db:"from_time"`
+	ToTime    time.Time `db:"to_time"`
+	Cursor    int64     `db:"cursor"`
+	Limit     int32     `db:"limit"`
+}
+
+type ListAccountEntriesAfterRow struct {
+	ID                    int64         `db:"id"`
+	AccountID             int64         `db:"account_id"`
+	Amount                int64         `db:"amount"`
+	CreatedAt             time.Time     `db:"created_at"`
+	TransferID            sql.NullInt64 `db:"transfer_id"`
+	Type                  EntryType     `db:"type"`
+	BalanceAfter          int64         `db:"balance_after"`
+	CounterpartyAccountID sql.NullInt64 `db:"counterparty_account_id"`
+}
+
+func (q *Queries) ListAccountEntriesAfter(ctx context.Context, arg ListAccountEntriesAfterParams) ([]ListAccountEntriesAfterRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountEntriesAfter,
+		arg.AccountID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.Cursor,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListAccountEntriesAfterRow{}
+	for rows.Next() {
+		var i ListAccountEntriesAfterRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.TransferID,
+			&i.Type,
+			&i.BalanceAfter,
+			&i.CounterpartyAccountID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listEntries = `-- name: ListEntries :many
-SELECT id, account_id, amount, created_at FROM entries
+SELECT id, account_id, amount, created_at, transfer_id, type, balance_after FROM entries
 ORDER BY id
 LIMIT $1
 OFFSET $2
@@ -85,6 +242,9 @@ func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Ent
 			&i.AccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.TransferID,
+			&i.Type,
+			&i.BalanceAfter,
 		); err != nil {
 			return nil, err
 		}
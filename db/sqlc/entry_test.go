@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"math"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -12,8 +13,10 @@ import (
 // createRandomEntry creates a random amount of entry by given account
 func createRandomEntry(t *testing.T, account Account) Entry {
 	arg := CreateEntryParams{
-		AccountID: account.ID,
-		Amount:    randomMoney(),
+		AccountID:    account.ID,
+		Amount:       randomMoney(),
+		Type:         EntryTypeDeposit,
+		BalanceAfter: randomMoney(),
 	}
 	entry, err := testQueries.CreateEntry(context.Background(), arg)
 	assert.NoError(t, err)
@@ -24,6 +27,9 @@ func createRandomEntry(t *testing.T, account Account) Entry {
 
 	assert.Equal(t, arg.AccountID, entry.AccountID)
 	assert.Equal(t, arg.Amount, entry.Amount)
+	assert.Equal(t, arg.Type, entry.Type)
+	assert.Equal(t, arg.BalanceAfter, entry.BalanceAfter)
+	assert.False(t, entry.TransferID.Valid)
 
 	return entry
 }
@@ -70,6 +76,121 @@ func TestListEntries(t *testing.T) {
 	assert.GreaterOrEqual(t, len(entries)-count, total)
 }
 
+// TestListAccountEntries makes sure list entries of given account with the balance after each of them
+func TestListAccountEntries(t *testing.T) {
+	account := createRandomAccount(t)
+	other := createRandomAccount(t)
+
+	from := time.Now().Add(-time.Minute)
+	var entries []Entry
+	for i := 0; i < 5; i++ {
+		entries = append(entries, createRandomEntry(t, account))
+		createRandomEntry(t, other)
+	}
+
+	rows, err := testQueries.ListAccountEntries(context.Background(), ListAccountEntriesParams{
+		AccountID: account.ID,
+		FromTime:  from,
+		ToTime:    time.Now().Add(time.Minute),
+		Limit:     math.MaxInt32,
+		Offset:    0,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, rows, len(entries))
+
+	for i, row := range rows {
+		assert.Equal(t, entries[i].ID, row.ID)
+		assert.Equal(t, account.ID, row.AccountID)
+		assert.Equal(t, entries[i].BalanceAfter, row.BalanceAfter)
+	}
+
+	rows, err = testQueries.ListAccountEntries(context.Background(), ListAccountEntriesParams{
+		AccountID: account.ID,
+		FromTime:  from,
+		ToTime:    time.Now().Add(time.Minute),
+		Limit:     1,
+		Offset:    int32(len(entries) - 1),
+	})
+	assert.NoError(t, err)
+	assert.Len(t, rows, 1)
+	assert.Equal(t, entries[len(entries)-1].BalanceAfter, rows[0].BalanceAfter)
+
+	// entries out of the time range are excluded
+	rows, err = testQueries.ListAccountEntries(context.Background(), ListAccountEntriesParams{
+		AccountID: account.ID,
+		FromTime:  time.Now().Add(time.Minute),
+		ToTime:    time.Now().Add(time.Hour),
+		Limit:     math.MaxInt32,
+		Offset:    0,
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, rows)
+}
+
+// TestListAccountEntriesCounterparty makes sure transfer entries show the other account
+func TestListAccountEntriesCounterparty(t *testing.T) {
+	account := createAccountInCurrency(t, "USD")
+	other := createAccountInCurrency(t, "USD")
+
+	from := time.Now().Add(-time.Minute)
+	deposit, err := testStore.DepositTx(context.Background(), DepositTxParams{AccountID: account.ID, Amount: 100})
+	assert.NoError(t, err)
+	result, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account.ID,
+		ToAccountID:   other.ID,
+		Amount:        30,
+	})
+	assert.NoError(t, err)
+
+	rows, err := testQueries.ListAccountEntries(context.Background(), ListAccountEntriesParams{
+		AccountID: account.ID,
+		FromTime:  from,
+		ToTime:    time.Now().Add(time.Minute),
+		Limit:     math.MaxInt32,
+		Offset:    0,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+
+	assert.Equal(t, deposit.Entry.ID, rows[0].ID)
+	assert.Equal(t, EntryTypeDeposit, rows[0].Type)
+	assert.Equal(t, deposit.Account.Balance, rows[0].BalanceAfter)
+	assert.False(t, rows[0].TransferID.Valid)
+	assert.False(t, rows[0].CounterpartyAccountID.Valid)
+
+	assert.Equal(t, result.FromEntry.ID, rows[1].ID)
+	assert.Equal(t, EntryTypeTransfer, rows[1].Type)
+	assert.Equal(t, sql.NullInt64{Int64: result.Transfer.ID, Valid: true}, rows[1].TransferID)
+	assert.Equal(t, sql.NullInt64{Int64: other.ID, Valid: true}, rows[1].CounterpartyAccountID)
+	assert.Equal(t, result.FromAccount.Balance, rows[1].BalanceAfter)
+	assert.Equal(t, deposit.Account.Balance-30, rows[1].BalanceAfter)
+}
+
+func TestListAccountEntriesAfter(t *testing.T) {
+	account := createRandomAccount(t)
+
+	from := time.Now().Add(-time.Minute)
+	var entries []Entry
+	for i := 0; i < 5; i++ {
+		entries = append(entries, createRandomEntry(t, account))
+	}
+
+	rows, err := testQueries.ListAccountEntriesAfter(context.Background(), ListAccountEntriesAfterParams{
+		AccountID: account.ID,
+		FromTime:  from,
+		ToTime:    time.Now().Add(time.Minute),
+		Cursor:    entries[2].ID,
+		Limit:     math.MaxInt32,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+
+	for i, row := range rows {
+		assert.Equal(t, entries[i+3].ID, row.ID)
+		assert.Equal(t, entries[i+3].BalanceAfter, row.BalanceAfter)
+	}
+}
+
 // TestDeleteEntry makes sure delete entry record by given ID
 func TestDeleteEntry(t *testing.T) {
 	entry1 := createRandomEntry(t, createRandomAccount(t))
@@ -0,0 +1,51 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// createTestExchangeRate creates an exchange rate effective from the given time
+func createTestExchangeRate(t *testing.T, from, to, rate string, effectiveAt time.Time) ExchangeRate {
+	arg := CreateExchangeRateParams{
+		FromCurrency: from,
+		ToCurrency:   to,
+		Rate:         rate,
+		EffectiveAt:  effectiveAt,
+	}
+	exchangeRate, err := testQueries.CreateExchangeRate(context.Background(), arg)
+	assert.NoError(t, err)
+
+	assert.Equal(t, arg.FromCurrency, exchangeRate.FromCurrency)
+	assert.Equal(t, arg.ToCurrency, exchangeRate.ToCurrency)
+	assert.Equal(t, arg.Rate, exchangeRate.Rate)
+	assert.WithinDuration(t, arg.EffectiveAt, exchangeRate.EffectiveAt, time.Second)
+
+	return exchangeRate
+}
+
+// TestGetExchangeRate makes sure the latest effective rate is returned
+func TestGetExchangeRate(t *testing.T) {
+	now := time.Now()
+	createTestExchangeRate(t, "EUR", "JPY", "140.5", now.Add(-time.Hour))
+	latest := createTestExchangeRate(t, "EUR", "JPY", "141.25", now.Add(-time.Minute))
+	createTestExchangeRate(t, "EUR", "JPY", "150", now.Add(time.Hour))
+
+	exchangeRate, err := testQueries.GetExchangeRate(context.Background(), GetExchangeRateParams{
+		FromCurrency: "EUR",
+		ToCurrency:   "JPY",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, latest.Rate, exchangeRate.Rate)
+	assert.WithinDuration(t, latest.EffectiveAt, exchangeRate.EffectiveAt, time.Second)
+
+	_, err = testQueries.GetExchangeRate(context.Background(), GetExchangeRateParams{
+		FromCurrency: "JPY",
+		ToCurrency:   "JPY",
+	})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,25 @@
+package db
+
+import (
+	"context"
+)
+
+// SchemaVersion is the state of the migrations applied to the db
+type SchemaVersion struct {
+	Version int64
+	// Dirty is set if the migration of the version failed halfway
+	Dirty bool
+}
+
+// Ping verifies the db is reachable
+func (s *SQLStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// GetSchemaVersion returns the version of the migrations applied to the db,
+// which golang-migrate records in the schema_migrations table
+func (s *SQLStore) GetSchemaVersion(ctx context.Context) (SchemaVersion, error) {
+	var v SchemaVersion
+	err := s.db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&v.Version, &v.Dirty)
+	return v, err
+}
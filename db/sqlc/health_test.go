@@ -0,0 +1,23 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/peienxie/go-bank/db/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPing(t *testing.T) {
+	assert.NoError(t, testStore.Ping(context.Background()))
+}
+
+func TestGetSchemaVersion(t *testing.T) {
+	latest, err := schema.LatestVersion()
+	assert.NoError(t, err)
+
+	version, err := testStore.GetSchemaVersion(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, latest, version.Version)
+	assert.False(t, version.Dirty)
+}
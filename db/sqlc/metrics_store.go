@@ -0,0 +1,480 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	dbDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gobank_db_duration_seconds",
+		Help:    "Latency of the Store methods in seconds.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method"})
+	dbErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gobank_db_errors_total",
+		Help: "Number of Store method calls that failed, not found results are not counted.",
+	}, []string{"method"})
+	transfersTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gobank_transfers_total",
+		Help: "Number of completed transfers by the currency of the from account.",
+	}, []string{"currency"})
+	transferAmountTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gobank_transfer_amount_total",
+		Help: "Volume of completed transfers in minor units of the currency of the from account.",
+	}, []string{"currency"})
+)
+
+// RegisterDBStatsMetrics exposes the connection pool stats of the db as the go_sql_* metrics
+func RegisterDBStatsMetrics(db *sql.DB) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, "gobank"))
+}
+
+// MetricsStore decorates a Store to record the latency and the errors of every method,
+// and the volume of the transfers it completes
+type MetricsStore struct {
+	store Store
+}
+
+var _ Store = (*MetricsStore)(nil)
+
+// NewMetricsStore creates a Store recording the metrics of the calls to store
+func NewMetricsStore(store Store) *MetricsStore {
+	return &MetricsStore{store: store}
+}
+
+// observe records a call of the method started at start, it's deferred with the named error result
+func (s *MetricsStore) observe(method string, start time.Time, err *error) {
+	dbDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if *err != nil && !errors.Is(*err, sql.ErrNoRows) {
+		dbErrors.WithLabelValues(method).Inc()
+	}
+}
+
+// observeTransfer records the volume of a completed transfer
+func observeTransfer(result TransferTxResult) {
+	transfersTotal.WithLabelValues(result.FromAccount.Currency).Inc()
+	transferAmountTotal.WithLabelValues(result.FromAccount.Currency).Add(float64(result.Transfer.Amount))
+}
+
+func (s *MetricsStore) AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (_ Account, err error) {
+	defer s.observe("AddAccountBalance", time.Now(), &err)
+	return s.store.AddAccountBalance(ctx, arg)
+}
+
+func (s *MetricsStore) AuthorizeTransferTx(ctx context.Context, arg TransferTxParams) (_ Transfer, err error) {
+	defer s.observe("AuthorizeTransferTx", time.Now(), &err)
+	return s.store.AuthorizeTransferTx(ctx, arg)
+}
+
+func (s *MetricsStore) BlockSession(ctx context.Context, id uuid.UUID) (_ Session, err error) {
+	defer s.observe("BlockSession", time.Now(), &err)
+	return s.store.BlockSession(ctx, id)
+}
+
+func (s *MetricsStore) CaptureHold(ctx context.Context, arg CaptureHoldParams) (_ Hold, err error) {
+	defer s.observe("CaptureHold", time.Now(), &err)
+	return s.store.CaptureHold(ctx, arg)
+}
+
+func (s *MetricsStore) CaptureHoldTx(ctx context.Context, arg CaptureHoldTxParams) (result CaptureHoldTxResult, err error) {
+	defer s.observe("CaptureHoldTx", time.Now(), &err)
+	result, err = s.store.CaptureHoldTx(ctx, arg)
+	if err == nil {
+		observeTransfer(result.TransferTxResult)
+	}
+	return result, err
+}
+
+func (s *MetricsStore) CreateAccount(ctx context.Context, arg CreateAccountParams) (_ Account, err error) {
+	defer s.observe("CreateAccount", time.Now(), &err)
+	return s.store.CreateAccount(ctx, arg)
+}
+
+func (s *MetricsStore) CreateEntry(ctx context.Context, arg CreateEntryParams) (_ Entry, err error) {
+	defer s.observe("CreateEntry", time.Now(), &err)
+	return s.store.CreateEntry(ctx, arg)
+}
+
+func (s *MetricsStore) CreateExchangeRate(ctx context.Context, arg CreateExchangeRateParams) (_ ExchangeRate, err error) {
+	defer s.observe("CreateExchangeRate", time.Now(), &err)
+	return s.store.CreateExchangeRate(ctx, arg)
+}
+
+func (s *MetricsStore) CreateHold(ctx context.Context, arg CreateHoldParams) (_ Hold, err error) {
+	defer s.observe("CreateHold", time.Now(), &err)
+	return s.store.CreateHold(ctx, arg)
+}
+
+func (s *MetricsStore) CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) (_ IdempotencyKey, err error) {
+	defer s.observe("CreateIdempotencyKey", time.Now(), &err)
+	return s.store.CreateIdempotencyKey(ctx, arg)
+}
+
+func (s *MetricsStore) CreateScheduledTransfer(ctx context.Context, arg CreateScheduledTransferParams) (_ ScheduledTransfer, err error) {
+	defer s.observe("CreateScheduledTransfer", time.Now(), &err)
+	return s.store.CreateScheduledTransfer(ctx, arg)
+}
+
+func (s *MetricsStore) CreateScheduledTransferRun(ctx context.Context, arg CreateScheduledTransferRunParams) (_ ScheduledTransferRun, err error) {
+	defer s.observe("CreateScheduledTransferRun", time.Now(), &err)
+	return s.store.CreateScheduledTransferRun(ctx, arg)
+}
+
+func (s *MetricsStore) CreateSession(ctx context.Context, arg CreateSessionParams) (_ Session, err error) {
+	defer s.observe("CreateSession", time.Now(), &err)
+	return s.store.CreateSession(ctx, arg)
+}
+
+func (s *MetricsStore) CreateTransfer(ctx context.Context, arg CreateTransferParams) (_ Transfer, err error) {
+	defer s.observe("CreateTransfer", time.Now(), &err)
+	return s.store.CreateTransfer(ctx, arg)
+}
+
+func (s *MetricsStore) CreateTransferStatusChange(ctx context.Context, arg CreateTransferStatusChangeParams) (_ TransferStatusChange, err error) {
+	defer s.observe("CreateTransferStatusChange", time.Now(), &err)
+	return s.store.CreateTransferStatusChange(ctx, arg)
+}
+
+func (s *MetricsStore) CreateUser(ctx context.Context, arg CreateUserParams) (_ User, err error) {
+	defer s.observe("CreateUser", time.Now(), &err)
+	return s.store.CreateUser(ctx, arg)
+}
+
+func (s *MetricsStore) DeleteAccount(ctx context.Context, id int64) (err error) {
+	defer s.observe("DeleteAccount", time.Now(), &err)
+	return s.store.DeleteAccount(ctx, id)
+}
+
+func (s *MetricsStore) DeleteEntry(ctx context.Context, id int64) (err error) {
+	defer s.observe("DeleteEntry", time.Now(), &err)
+	return s.store.DeleteEntry(ctx, id)
+}
+
+func (s *MetricsStore) DeleteScheduledTransfer(ctx context.Context, id int64) (err error) {
+	defer s.observe("DeleteScheduledTransfer", time.Now(), &err)
+	return s.store.DeleteScheduledTransfer(ctx, id)
+}
+
+func (s *MetricsStore) DeleteTransfer(ctx context.Context, id int64) (err error) {
+	defer s.observe("DeleteTransfer", time.Now(), &err)
+	return s.store.DeleteTransfer(ctx, id)
+}
+
+func (s *MetricsStore) DepositTx(ctx context.Context, arg DepositTxParams) (_ DepositTxResult, err error) {
+	defer s.observe("DepositTx", time.Now(), &err)
+	return s.store.DepositTx(ctx, arg)
+}
+
+func (s *MetricsStore) ExpireHolds(ctx context.Context) (_ int64, err error) {
+	defer s.observe("ExpireHolds", time.Now(), &err)
+	return s.store.ExpireHolds(ctx)
+}
+
+func (s *MetricsStore) FXTransferTx(ctx context.Context, arg FXTransferTxParams) (result TransferTxResult, err error) {
+	defer s.observe("FXTransferTx", time.Now(), &err)
+	result, err = s.store.FXTransferTx(ctx, arg)
+	if err == nil {
+		observeTransfer(result)
+	}
+	return result, err
+}
+
+func (s *MetricsStore) FailTransferTx(ctx context.Context, transferID int64) (_ Transfer, err error) {
+	defer s.observe("FailTransferTx", time.Now(), &err)
+	return s.store.FailTransferTx(ctx, transferID)
+}
+
+func (s *MetricsStore) GetAccount(ctx context.Context, id int64) (_ Account, err error) {
+	defer s.observe("GetAccount", time.Now(), &err)
+	return s.store.GetAccount(ctx, id)
+}
+
+func (s *MetricsStore) GetAccountForUpdate(ctx context.Context, id int64) (_ Account, err error) {
+	defer s.observe("GetAccountForUpdate", time.Now(), &err)
+	return s.store.GetAccountForUpdate(ctx, id)
+}
+
+func (s *MetricsStore) GetAccountHeldAmount(ctx context.Context, accountID int64) (_ int64, err error) {
+	defer s.observe("GetAccountHeldAmount", time.Now(), &err)
+	return s.store.GetAccountHeldAmount(ctx, accountID)
+}
+
+func (s *MetricsStore) GetCurrency(ctx context.Context, code string) (_ Currency, err error) {
+	defer s.observe("GetCurrency", time.Now(), &err)
+	return s.store.GetCurrency(ctx, code)
+}
+
+func (s *MetricsStore) GetDueScheduledTransferForUpdate(ctx context.Context) (_ ScheduledTransfer, err error) {
+	defer s.observe("GetDueScheduledTransferForUpdate", time.Now(), &err)
+	return s.store.GetDueScheduledTransferForUpdate(ctx)
+}
+
+func (s *MetricsStore) GetEntry(ctx context.Context, id int64) (_ Entry, err error) {
+	defer s.observe("GetEntry", time.Now(), &err)
+	return s.store.GetEntry(ctx, id)
+}
+
+func (s *MetricsStore) GetExchangeRate(ctx context.Context, arg GetExchangeRateParams) (_ ExchangeRate, err error) {
+	defer s.observe("GetExchangeRate", time.Now(), &err)
+	return s.store.GetExchangeRate(ctx, arg)
+}
+
+func (s *MetricsStore) GetHold(ctx context.Context, id int64) (_ Hold, err error) {
+	defer s.observe("GetHold", time.Now(), &err)
+	return s.store.GetHold(ctx, id)
+}
+
+func (s *MetricsStore) GetHoldForUpdate(ctx context.Context, id int64) (_ Hold, err error) {
+	defer s.observe("GetHoldForUpdate", time.Now(), &err)
+	return s.store.GetHoldForUpdate(ctx, id)
+}
+
+func (s *MetricsStore) GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (_ IdempotencyKey, err error) {
+	defer s.observe("GetIdempotencyKey", time.Now(), &err)
+	return s.store.GetIdempotencyKey(ctx, arg)
+}
+
+func (s *MetricsStore) GetScheduledTransfer(ctx context.Context, id int64) (_ ScheduledTransfer, err error) {
+	defer s.observe("GetScheduledTransfer", time.Now(), &err)
+	return s.store.GetScheduledTransfer(ctx, id)
+}
+
+func (s *MetricsStore) GetSchemaVersion(ctx context.Context) (_ SchemaVersion, err error) {
+	defer s.observe("GetSchemaVersion", time.Now(), &err)
+	return s.store.GetSchemaVersion(ctx)
+}
+
+func (s *MetricsStore) GetSession(ctx context.Context, id uuid.UUID) (_ Session, err error) {
+	defer s.observe("GetSession", time.Now(), &err)
+	return s.store.GetSession(ctx, id)
+}
+
+func (s *MetricsStore) GetTransfer(ctx context.Context, id int64) (_ Transfer, err error) {
+	defer s.observe("GetTransfer", time.Now(), &err)
+	return s.store.GetTransfer(ctx, id)
+}
+
+func (s *MetricsStore) GetTransferForUpdate(ctx context.Context, id int64) (_ Transfer, err error) {
+	defer s.observe("GetTransferForUpdate", time.Now(), &err)
+	return s.store.GetTransferForUpdate(ctx, id)
+}
+
+func (s *MetricsStore) GetUser(ctx context.Context, username string) (_ User, err error) {
+	defer s.observe("GetUser", time.Now(), &err)
+	return s.store.GetUser(ctx, username)
+}
+
+func (s *MetricsStore) IdempotentTransferTx(ctx context.Context, arg IdempotentTransferTxParams) (result IdempotentTransferTxResult, err error) {
+	defer s.observe("IdempotentTransferTx", time.Now(), &err)
+	result, err = s.store.IdempotentTransferTx(ctx, arg)
+	if err == nil && !result.Replayed {
+		observeTransfer(result.TransferTxResult)
+	}
+	return result, err
+}
+
+func (s *MetricsStore) ListAccountEntries(ctx context.Context, arg ListAccountEntriesParams) (_ []ListAccountEntriesRow, err error) {
+	defer s.observe("ListAccountEntries", time.Now(), &err)
+	return s.store.ListAccountEntries(ctx, arg)
+}
+
+func (s *MetricsStore) ListAccountEntriesAfter(ctx context.Context, arg ListAccountEntriesAfterParams) (_ []ListAccountEntriesAfterRow, err error) {
+	defer s.observe("ListAccountEntriesAfter", time.Now(), &err)
+	return s.store.ListAccountEntriesAfter(ctx, arg)
+}
+
+func (s *MetricsStore) ListAccounts(ctx context.Context, arg ListAccountsParams) (_ []Account, err error) {
+	defer s.observe("ListAccounts", time.Now(), &err)
+	return s.store.ListAccounts(ctx, arg)
+}
+
+func (s *MetricsStore) ListAccountsByUsername(ctx context.Context, arg ListAccountsByUsernameParams) (_ []Account, err error) {
+	defer s.observe("ListAccountsByUsername", time.Now(), &err)
+	return s.store.ListAccountsByUsername(ctx, arg)
+}
+
+func (s *MetricsStore) ListAccountsByUsernameAfter(ctx context.Context, arg ListAccountsByUsernameAfterParams) (_ []Account, err error) {
+	defer s.observe("ListAccountsByUsernameAfter", time.Now(), &err)
+	return s.store.ListAccountsByUsernameAfter(ctx, arg)
+}
+
+func (s *MetricsStore) ListBalanceMismatches(ctx context.Context) (_ []ListBalanceMismatchesRow, err error) {
+	defer s.observe("ListBalanceMismatches", time.Now(), &err)
+	return s.store.ListBalanceMismatches(ctx)
+}
+
+func (s *MetricsStore) ListCurrencies(ctx context.Context) (_ []Currency, err error) {
+	defer s.observe("ListCurrencies", time.Now(), &err)
+	return s.store.ListCurrencies(ctx)
+}
+
+func (s *MetricsStore) ListEntries(ctx context.Context, arg ListEntriesParams) (_ []Entry, err error) {
+	defer s.observe("ListEntries", time.Now(), &err)
+	return s.store.ListEntries(ctx, arg)
+}
+
+func (s *MetricsStore) ListScheduledTransferRuns(ctx context.Context, scheduledTransferID int64) (_ []ScheduledTransferRun, err error) {
+	defer s.observe("ListScheduledTransferRuns", time.Now(), &err)
+	return s.store.ListScheduledTransferRuns(ctx, scheduledTransferID)
+}
+
+func (s *MetricsStore) ListScheduledTransfersByUsername(ctx context.Context, arg ListScheduledTransfersByUsernameParams) (_ []ScheduledTransfer, err error) {
+	defer s.observe("ListScheduledTransfersByUsername", time.Now(), &err)
+	return s.store.ListScheduledTransfersByUsername(ctx, arg)
+}
+
+func (s *MetricsStore) ListScheduledTransfersByUsernameAfter(ctx context.Context, arg ListScheduledTransfersByUsernameAfterParams) (_ []ScheduledTransfer, err error) {
+	defer s.observe("ListScheduledTransfersByUsernameAfter", time.Now(), &err)
+	return s.store.ListScheduledTransfersByUsernameAfter(ctx, arg)
+}
+
+func (s *MetricsStore) ListTransferStatusChanges(ctx context.Context, transferID int64) (_ []TransferStatusChange, err error) {
+	defer s.observe("ListTransferStatusChanges", time.Now(), &err)
+	return s.store.ListTransferStatusChanges(ctx, transferID)
+}
+
+func (s *MetricsStore) ListTransfers(ctx context.Context, arg ListTransfersParams) (_ []Transfer, err error) {
+	defer s.observe("ListTransfers", time.Now(), &err)
+	return s.store.ListTransfers(ctx, arg)
+}
+
+func (s *MetricsStore) ListTransfersByAccount(ctx context.Context, arg ListTransfersByAccountParams) (_ []Transfer, err error) {
+	defer s.observe("ListTransfersByAccount", time.Now(), &err)
+	return s.store.ListTransfersByAccount(ctx, arg)
+}
+
+func (s *MetricsStore) ListTransfersByAccountAfter(ctx context.Context, arg ListTransfersByAccountAfterParams) (_ []Transfer, err error) {
+	defer s.observe("ListTransfersByAccountAfter", time.Now(), &err)
+	return s.store.ListTransfersByAccountAfter(ctx, arg)
+}
+
+func (s *MetricsStore) ListUnmatchedTransfers(ctx context.Context) (_ []ListUnmatchedTransfersRow, err error) {
+	defer s.observe("ListUnmatchedTransfers", time.Now(), &err)
+	return s.store.ListUnmatchedTransfers(ctx)
+}
+
+func (s *MetricsStore) LockIdempotencyKey(ctx context.Context, arg LockIdempotencyKeyParams) (err error) {
+	defer s.observe("LockIdempotencyKey", time.Now(), &err)
+	return s.store.LockIdempotencyKey(ctx, arg)
+}
+
+func (s *MetricsStore) Ping(ctx context.Context) (err error) {
+	defer s.observe("Ping", time.Now(), &err)
+	return s.store.Ping(ctx)
+}
+
+func (s *MetricsStore) PlaceHoldTx(ctx context.Context, arg PlaceHoldTxParams) (_ Hold, err error) {
+	defer s.observe("PlaceHoldTx", time.Now(), &err)
+	return s.store.PlaceHoldTx(ctx, arg)
+}
+
+func (s *MetricsStore) Reconcile(ctx context.Context) (_ ReconciliationReport, err error) {
+	defer s.observe("Reconcile", time.Now(), &err)
+	return s.store.Reconcile(ctx)
+}
+
+func (s *MetricsStore) ReleaseHoldTx(ctx context.Context, holdID int64) (_ Hold, err error) {
+	defer s.observe("ReleaseHoldTx", time.Now(), &err)
+	return s.store.ReleaseHoldTx(ctx, holdID)
+}
+
+func (s *MetricsStore) ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (_ TransferTxResult, err error) {
+	defer s.observe("ReverseTransferTx", time.Now(), &err)
+	return s.store.ReverseTransferTx(ctx, arg)
+}
+
+func (s *MetricsStore) ScheduledTransferTx(ctx context.Context, fn func(q ScheduledTransferQuerier, scheduled ScheduledTransfer) error) (err error) {
+	defer s.observe("ScheduledTransferTx", time.Now(), &err)
+
+	// the transfer of the run is observed once the transaction is committed
+	var transfers []TransferTxResult
+	err = s.store.ScheduledTransferTx(ctx, func(q ScheduledTransferQuerier, scheduled ScheduledTransfer) error {
+		return fn(&observedTransferQuerier{ScheduledTransferQuerier: q, transfers: &transfers}, scheduled)
+	})
+	if err == nil {
+		for _, result := range transfers {
+			observeTransfer(result)
+		}
+	}
+	return err
+}
+
+// observedTransferQuerier collects the transfers made in the transaction of a scheduled transfer run
+type observedTransferQuerier struct {
+	ScheduledTransferQuerier
+	transfers *[]TransferTxResult
+}
+
+func (q *observedTransferQuerier) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
+	result, err := q.ScheduledTransferQuerier.TransferTx(ctx, arg)
+	if err == nil {
+		*q.transfers = append(*q.transfers, result)
+	}
+	return result, err
+}
+
+func (s *MetricsStore) SettleTransferTx(ctx context.Context, transferID int64) (result TransferTxResult, err error) {
+	defer s.observe("SettleTransferTx", time.Now(), &err)
+	result, err = s.store.SettleTransferTx(ctx, transferID)
+	if err == nil {
+		observeTransfer(result)
+	}
+	return result, err
+}
+
+func (s *MetricsStore) TransferTx(ctx context.Context, arg TransferTxParams) (result TransferTxResult, err error) {
+	defer s.observe("TransferTx", time.Now(), &err)
+	result, err = s.store.TransferTx(ctx, arg)
+	if err == nil {
+		observeTransfer(result)
+	}
+	return result, err
+}
+
+func (s *MetricsStore) UpdateAccountBalance(ctx context.Context, arg UpdateAccountBalanceParams) (_ Account, err error) {
+	defer s.observe("UpdateAccountBalance", time.Now(), &err)
+	return s.store.UpdateAccountBalance(ctx, arg)
+}
+
+func (s *MetricsStore) UpdateAccountOverdraftLimit(ctx context.Context, arg UpdateAccountOverdraftLimitParams) (_ Account, err error) {
+	defer s.observe("UpdateAccountOverdraftLimit", time.Now(), &err)
+	return s.store.UpdateAccountOverdraftLimit(ctx, arg)
+}
+
+func (s *MetricsStore) UpdateHoldStatus(ctx context.Context, arg UpdateHoldStatusParams) (_ Hold, err error) {
+	defer s.observe("UpdateHoldStatus", time.Now(), &err)
+	return s.store.UpdateHoldStatus(ctx, arg)
+}
+
+func (s *MetricsStore) UpdateScheduledTransfer(ctx context.Context, arg UpdateScheduledTransferParams) (_ ScheduledTransfer, err error) {
+	defer s.observe("UpdateScheduledTransfer", time.Now(), &err)
+	return s.store.UpdateScheduledTransfer(ctx, arg)
+}
+
+func (s *MetricsStore) UpdateScheduledTransferNextRun(ctx context.Context, arg UpdateScheduledTransferNextRunParams) (_ ScheduledTransfer, err error) {
+	defer s.observe("UpdateScheduledTransferNextRun", time.Now(), &err)
+	return s.store.UpdateScheduledTransferNextRun(ctx, arg)
+}
+
+func (s *MetricsStore) UpdateTransferReversal(ctx context.Context, arg UpdateTransferReversalParams) (_ Transfer, err error) {
+	defer s.observe("UpdateTransferReversal", time.Now(), &err)
+	return s.store.UpdateTransferReversal(ctx, arg)
+}
+
+func (s *MetricsStore) UpdateTransferStatus(ctx context.Context, arg UpdateTransferStatusParams) (_ Transfer, err error) {
+	defer s.observe("UpdateTransferStatus", time.Now(), &err)
+	return s.store.UpdateTransferStatus(ctx, arg)
+}
+
+func (s *MetricsStore) WithdrawTx(ctx context.Context, arg WithdrawTxParams) (_ WithdrawTxResult, err error) {
+	defer s.observe("WithdrawTx", time.Now(), &err)
+	return s.store.WithdrawTx(ctx, arg)
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubStore returns the fixed result of TransferTx and GetAccount, other methods are not implemented
+type stubStore struct {
+	Store
+	result TransferTxResult
+	err    error
+}
+
+func (s stubStore) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
+	return s.result, s.err
+}
+
+func (s stubStore) GetAccount(ctx context.Context, id int64) (Account, error) {
+	return Account{}, s.err
+}
+
+func scrapeMetrics(t *testing.T) string {
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/metrics", nil)
+	assert.NoError(t, err)
+
+	promhttp.Handler().ServeHTTP(recorder, request)
+	return recorder.Body.String()
+}
+
+func TestMetricsStore(t *testing.T) {
+	result := TransferTxResult{
+		Transfer:    Transfer{Amount: 150},
+		FromAccount: Account{Currency: "XTS"},
+	}
+
+	store := NewMetricsStore(stubStore{result: result})
+	_, err := store.TransferTx(context.Background(), TransferTxParams{})
+	assert.NoError(t, err)
+	_, err = store.TransferTx(context.Background(), TransferTxParams{})
+	assert.NoError(t, err)
+
+	store = NewMetricsStore(stubStore{result: result, err: errors.New("failed")})
+	_, err = store.TransferTx(context.Background(), TransferTxParams{})
+	assert.Error(t, err)
+
+	store = NewMetricsStore(stubStore{err: sql.ErrNoRows})
+	_, err = store.GetAccount(context.Background(), 1)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	body := scrapeMetrics(t)
+	assert.Contains(t, body, `gobank_db_duration_seconds_count{method="TransferTx"} 3`)
+	assert.Contains(t, body, `gobank_db_errors_total{method="TransferTx"} 1`)
+	assert.Contains(t, body, `gobank_db_duration_seconds_count{method="GetAccount"} 1`)
+	assert.NotContains(t, body, `gobank_db_errors_total{method="GetAccount"}`)
+	assert.Contains(t, body, `gobank_transfers_total{currency="XTS"} 2`)
+	assert.Contains(t, body, `gobank_transfer_amount_total{currency="XTS"} 300`)
+}
@@ -3,28 +3,227 @@
 package db
 
 import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
 	"time"
+
+	"github.com/google/uuid"
+)
+
+type EntryType string
+
+const (
+	EntryTypeTransfer   EntryType = "transfer"
+	EntryTypeDeposit    EntryType = "deposit"
+	EntryTypeWithdrawal EntryType = "withdrawal"
+	EntryTypeFee        EntryType = "fee"
+	EntryTypeReversal   EntryType = "reversal"
+)
+
+func (e *EntryType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = EntryType(s)
+	case string:
+		*e = EntryType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for EntryType: %T", src)
+	}
+	return nil
+}
+
+type HoldStatus string
+
+const (
+	HoldStatusActive   HoldStatus = "active"
+	HoldStatusCaptured HoldStatus = "captured"
+	HoldStatusReleased HoldStatus = "released"
+	HoldStatusExpired  HoldStatus = "expired"
+)
+
+func (e *HoldStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = HoldStatus(s)
+	case string:
+		*e = HoldStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for HoldStatus: %T", src)
+	}
+	return nil
+}
+
+type ScheduledTransferRunStatus string
+
+const (
+	ScheduledTransferRunStatusSucceeded ScheduledTransferRunStatus = "succeeded"
+	ScheduledTransferRunStatusFailed    ScheduledTransferRunStatus = "failed"
+)
+
+func (e *ScheduledTransferRunStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ScheduledTransferRunStatus(s)
+	case string:
+		*e = ScheduledTransferRunStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ScheduledTransferRunStatus: %T", src)
+	}
+	return nil
+}
+
+type TransferStatus string
+
+const (
+	TransferStatusPending   TransferStatus = "pending"
+	TransferStatusCompleted TransferStatus = "completed"
+	TransferStatusFailed    TransferStatus = "failed"
+	TransferStatusReversed  TransferStatus = "reversed"
+)
+
+func (e *TransferStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = TransferStatus(s)
+	case string:
+		*e = TransferStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for TransferStatus: %T", src)
+	}
+	return nil
+}
+
+type UserRole string
+
+const (
+	UserRoleCustomer UserRole = "customer"
+	UserRoleOperator UserRole = "operator"
 )
 
+func (e *UserRole) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = UserRole(s)
+	case string:
+		*e = UserRole(s)
+	default:
+		return fmt.Errorf("unsupported scan type for UserRole: %T", src)
+	}
+	return nil
+}
+
 type Account struct {
-	ID        int64     `db:"id"`
-	Username  string    `db:"username"`
-	Balance   int64     `db:"balance"`
-	Currency  string    `db:"currency"`
-	CreatedAt time.Time `db:"created_at"`
+	ID             int64     `db:"id"`
+	Username       string    `db:"username"`
+	Balance        int64     `db:"balance"`
+	Currency       string    `db:"currency"`
+	CreatedAt      time.Time `db:"created_at"`
+	OverdraftLimit int64     `db:"overdraft_limit"`
+}
+
+type Currency struct {
+	Code     string `db:"code"`
+	Exponent int32  `db:"exponent"`
+	Symbol   string `db:"symbol"`
 }
 
 type Entry struct {
-	ID        int64     `db:"id"`
-	AccountID int64     `db:"account_id"`
-	Amount    int64     `db:"amount"`
-	CreatedAt time.Time `db:"created_at"`
+	ID           int64         `db:"id"`
+	AccountID    int64         `db:"account_id"`
+	Amount       int64         `db:"amount"`
+	CreatedAt    time.Time     `db:"created_at"`
+	TransferID   sql.NullInt64 `db:"transfer_id"`
+	Type         EntryType     `db:"type"`
+	BalanceAfter int64         `db:"balance_after"`
+}
+
+type ExchangeRate struct {
+	FromCurrency string    `db:"from_currency"`
+	ToCurrency   string    `db:"to_currency"`
+	Rate         string    `db:"rate"`
+	EffectiveAt  time.Time `db:"effective_at"`
+}
+
+type Hold struct {
+	ID          int64         `db:"id"`
+	AccountID   int64         `db:"account_id"`
+	ToAccountID int64         `db:"to_account_id"`
+	Amount      int64         `db:"amount"`
+	Status      HoldStatus    `db:"status"`
+	TransferID  sql.NullInt64 `db:"transfer_id"`
+	ExpiresAt   time.Time     `db:"expires_at"`
+	CreatedAt   time.Time     `db:"created_at"`
+}
+
+type IdempotencyKey struct {
+	Username       string          `db:"username"`
+	IdempotencyKey string          `db:"idempotency_key"`
+	RequestHash    string          `db:"request_hash"`
+	Response       json.RawMessage `db:"response"`
+	CreatedAt      time.Time       `db:"created_at"`
+}
+
+type ScheduledTransfer struct {
+	ID            int64        `db:"id"`
+	Username      string       `db:"username"`
+	FromAccountID int64        `db:"from_account_id"`
+	ToAccountID   int64        `db:"to_account_id"`
+	Amount        int64        `db:"amount"`
+	Currency      string       `db:"currency"`
+	Schedule      string       `db:"schedule"`
+	StartAt       time.Time    `db:"start_at"`
+	NextRunAt     sql.NullTime `db:"next_run_at"`
+	EndAt         sql.NullTime `db:"end_at"`
+	CreatedAt     time.Time    `db:"created_at"`
+}
+
+type ScheduledTransferRun struct {
+	ID                  int64                      `db:"id"`
+	ScheduledTransferID int64                      `db:"scheduled_transfer_id"`
+	Status              ScheduledTransferRunStatus `db:"status"`
+	TransferID          sql.NullInt64              `db:"transfer_id"`
+	Error               string                     `db:"error"`
+	CreatedAt           time.Time                  `db:"created_at"`
+}
+
+type Session struct {
+	ID           uuid.UUID `db:"id"`
+	Username     string    `db:"username"`
+	RefreshToken string    `db:"refresh_token"`
+	UserAgent    string    `db:"user_agent"`
+	ClientIp     string    `db:"client_ip"`
+	IsBlocked    bool      `db:"is_blocked"`
+	ExpiresAt    time.Time `db:"expires_at"`
+	CreatedAt    time.Time `db:"created_at"`
 }
 
 type Transfer struct {
-	ID            int64     `db:"id"`
-	FromAccountID int64     `db:"from_account_id"`
-	ToAccountID   int64     `db:"to_account_id"`
-	Amount        int64     `db:"amount"`
-	CreatedAt     time.Time `db:"created_at"`
+	ID             int64          `db:"id"`
+	FromAccountID  int64          `db:"from_account_id"`
+	ToAccountID    int64          `db:"to_account_id"`
+	Amount         int64          `db:"amount"`
+	CreatedAt      time.Time      `db:"created_at"`
+	ToAmount       int64          `db:"to_amount"`
+	ExchangeRate   string         `db:"exchange_rate"`
+	ReversedAmount int64          `db:"reversed_amount"`
+	ReversedAt     sql.NullTime   `db:"reversed_at"`
+	Status         TransferStatus `db:"status"`
+}
+
+type TransferStatusChange struct {
+	ID         int64          `db:"id"`
+	TransferID int64          `db:"transfer_id"`
+	Status     TransferStatus `db:"status"`
+	CreatedAt  time.Time      `db:"created_at"`
+}
+
+type User struct {
+	Username          string    `db:"username"`
+	HashedPassword    string    `db:"hashed_password"`
+	FullName          string    `db:"full_name"`
+	Email             string    `db:"email"`
+	PasswordChangedAt time.Time `db:"password_changed_at"`
+	CreatedAt         time.Time `db:"created_at"`
+	Role              UserRole  `db:"role"`
 }
@@ -4,23 +4,69 @@ package db
 
 import (
 	"context"
+
+	"github.com/google/uuid"
 )
 
 type Querier interface {
 	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error)
+	BlockSession(ctx context.Context, id uuid.UUID) (Session, error)
+	CaptureHold(ctx context.Context, arg CaptureHoldParams) (Hold, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
 	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
+	CreateExchangeRate(ctx context.Context, arg CreateExchangeRateParams) (ExchangeRate, error)
+	CreateHold(ctx context.Context, arg CreateHoldParams) (Hold, error)
+	CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) (IdempotencyKey, error)
+	CreateScheduledTransfer(ctx context.Context, arg CreateScheduledTransferParams) (ScheduledTransfer, error)
+	CreateScheduledTransferRun(ctx context.Context, arg CreateScheduledTransferRunParams) (ScheduledTransferRun, error)
+	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
+	CreateTransferStatusChange(ctx context.Context, arg CreateTransferStatusChangeParams) (TransferStatusChange, error)
+	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
 	DeleteAccount(ctx context.Context, id int64) error
 	DeleteEntry(ctx context.Context, id int64) error
+	DeleteScheduledTransfer(ctx context.Context, id int64) error
 	DeleteTransfer(ctx context.Context, id int64) error
+	ExpireHolds(ctx context.Context) (int64, error)
 	GetAccount(ctx context.Context, id int64) (Account, error)
+	GetAccountForUpdate(ctx context.Context, id int64) (Account, error)
+	GetAccountHeldAmount(ctx context.Context, accountID int64) (int64, error)
+	GetCurrency(ctx context.Context, code string) (Currency, error)
+	GetDueScheduledTransferForUpdate(ctx context.Context) (ScheduledTransfer, error)
 	GetEntry(ctx context.Context, id int64) (Entry, error)
+	GetExchangeRate(ctx context.Context, arg GetExchangeRateParams) (ExchangeRate, error)
+	GetHold(ctx context.Context, id int64) (Hold, error)
+	GetHoldForUpdate(ctx context.Context, id int64) (Hold, error)
+	GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error)
+	GetScheduledTransfer(ctx context.Context, id int64) (ScheduledTransfer, error)
+	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
 	GetTransfer(ctx context.Context, id int64) (Transfer, error)
+	GetTransferForUpdate(ctx context.Context, id int64) (Transfer, error)
+	GetUser(ctx context.Context, username string) (User, error)
+	ListAccountEntries(ctx context.Context, arg ListAccountEntriesParams) ([]ListAccountEntriesRow, error)
+	ListAccountEntriesAfter(ctx context.Context, arg ListAccountEntriesAfterParams) ([]ListAccountEntriesAfterRow, error)
 	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
+	ListAccountsByUsername(ctx context.Context, arg ListAccountsByUsernameParams) ([]Account, error)
+	ListAccountsByUsernameAfter(ctx context.Context, arg ListAccountsByUsernameAfterParams) ([]Account, error)
+	ListBalanceMismatches(ctx context.Context) ([]ListBalanceMismatchesRow, error)
+	ListCurrencies(ctx context.Context) ([]Currency, error)
 	ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error)
+	ListScheduledTransferRuns(ctx context.Context, scheduledTransferID int64) ([]ScheduledTransferRun, error)
+	ListScheduledTransfersByUsername(ctx context.Context, arg ListScheduledTransfersByUsernameParams) ([]ScheduledTransfer, error)
+	ListScheduledTransfersByUsernameAfter(ctx context.Context, arg ListScheduledTransfersByUsernameAfterParams) ([]ScheduledTransfer, error)
+	ListTransferStatusChanges(ctx context.Context, transferID int64) ([]TransferStatusChange, error)
 	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
+	ListTransfersByAccount(ctx context.Context, arg ListTransfersByAccountParams) ([]Transfer, error)
+	ListTransfersByAccountAfter(ctx context.Context, arg ListTransfersByAccountAfterParams) ([]Transfer, error)
+	ListUnmatchedTransfers(ctx context.Context) ([]ListUnmatchedTransfersRow, error)
+	LockIdempotencyKey(ctx context.Context, arg LockIdempotencyKeyParams) error
 	UpdateAccountBalance(ctx context.Context, arg UpdateAccountBalanceParams) (Account, error)
+	UpdateAccountOverdraftLimit(ctx context.Context, arg UpdateAccountOverdraftLimitParams) (Account, error)
+	UpdateHoldStatus(ctx context.Context, arg UpdateHoldStatusParams) (Hold, error)
+	UpdateScheduledTransfer(ctx context.Context, arg UpdateScheduledTransferParams) (ScheduledTransfer, error)
+	UpdateScheduledTransferNextRun(ctx context.Context, arg UpdateScheduledTransferNextRunParams) (ScheduledTransfer, error)
+	UpdateTransferReversal(ctx context.Context, arg UpdateTransferReversalParams) (Transfer, error)
+	UpdateTransferStatus(ctx context.Context, arg UpdateTransferStatusParams) (Transfer, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,44 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// ReconciliationReport lists the ledger discrepancies found by Reconcile
+type ReconciliationReport struct {
+	CheckedAt time.Time `json:"checked_at"`
+	// BalanceMismatches are the accounts whose balance differs from the sum of their entries
+	BalanceMismatches []ListBalanceMismatchesRow `json:"balance_mismatches"`
+	// UnmatchedTransfers are the transfers without exactly one debit and one credit entry
+	UnmatchedTransfers []ListUnmatchedTransfersRow `json:"unmatched_transfers"`
+}
+
+// OK reports whether no discrepancy is found
+func (r ReconciliationReport) OK() bool {
+	return len(r.BalanceMismatches) == 0 && len(r.UnmatchedTransfers) == 0
+}
+
+// Reconcile verifies the double-entry ledger invariants. Every account balance must equal
+// the sum of its entries, and every transfer must have exactly one matching debit and credit entry.
+// Both checks run on the same snapshot of the database so concurrent transfers are not reported
+func (s *SQLStore) Reconcile(ctx context.Context) (ReconciliationReport, error) {
+	var report ReconciliationReport
+
+	opts := &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+	err := s.execTxWithOptions(ctx, opts, func(q *Queries) error {
+		var err error
+		report.CheckedAt = time.Now()
+
+		report.BalanceMismatches, err = q.ListBalanceMismatches(ctx)
+		if err != nil {
+			return err
+		}
+
+		report.UnmatchedTransfers, err = q.ListUnmatchedTransfers(ctx)
+		return err
+	})
+
+	return report, err
+}
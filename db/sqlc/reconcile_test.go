@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconcile(t *testing.T) {
+	// balanced accounts: funded by deposit entries and moved by a transfer
+	account1 := createAccountInCurrency(t, "USD")
+	account2 := createAccountInCurrency(t, "USD")
+	_, err := testStore.DepositTx(context.Background(), DepositTxParams{AccountID: account1.ID, Amount: 100})
+	assert.NoError(t, err)
+	result, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        40,
+	})
+	assert.NoError(t, err)
+
+	// drifted account: balance updated without an entry
+	drifted := fundAccount(t, createAccountInCurrency(t, "USD"), 50)
+	// completed transfer recorded without entries
+	unmatched, err := testQueries.UpdateTransferStatus(context.Background(), UpdateTransferStatusParams{
+		ID:     createRandomTransfer(t, account1, account2).ID,
+		Status: TransferStatusCompleted,
+	})
+	assert.NoError(t, err)
+	// pending transfer has no entries until it's settled
+	pending := createRandomTransfer(t, account1, account2)
+
+	report, err := testStore.Reconcile(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, report.OK())
+	assert.NotZero(t, report.CheckedAt)
+
+	mismatches := make(map[int64]ListBalanceMismatchesRow)
+	for _, row := range report.BalanceMismatches {
+		mismatches[row.AccountID] = row
+	}
+	assert.NotContains(t, mismatches, account1.ID)
+	assert.NotContains(t, mismatches, account2.ID)
+	assert.Contains(t, mismatches, drifted.ID)
+	assert.Equal(t, int64(50), mismatches[drifted.ID].Balance)
+	assert.Equal(t, int64(0), mismatches[drifted.ID].EntriesSum)
+
+	unmatchedTransfers := make(map[int64]ListUnmatchedTransfersRow)
+	for _, row := range report.UnmatchedTransfers {
+		unmatchedTransfers[row.TransferID] = row
+	}
+	assert.NotContains(t, unmatchedTransfers, result.Transfer.ID)
+	assert.NotContains(t, unmatchedTransfers, pending.ID)
+	assert.Contains(t, unmatchedTransfers, unmatched.ID)
+	assert.Equal(t, int64(0), unmatchedTransfers[unmatched.ID].DebitEntries)
+	assert.Equal(t, int64(0), unmatchedTransfers[unmatched.ID].CreditEntries)
+}
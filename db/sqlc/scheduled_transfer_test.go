@@ -0,0 +1,183 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// createRandomScheduledTransfer creates a daily scheduled transfer between the accounts of the same currency
+func createRandomScheduledTransfer(t *testing.T, from, to Account, nextRunAt time.Time) ScheduledTransfer {
+	arg := CreateScheduledTransferParams{
+		Username:      from.Username,
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        randomMoney(),
+		Currency:      from.Currency,
+		Schedule:      "@daily",
+		StartAt:       nextRunAt,
+		NextRunAt:     sql.NullTime{Time: nextRunAt, Valid: true},
+	}
+	scheduled, err := testQueries.CreateScheduledTransfer(context.Background(), arg)
+	assert.NoError(t, err)
+
+	assert.NotZero(t, scheduled.ID)
+	assert.NotZero(t, scheduled.CreatedAt)
+	assert.Equal(t, arg.Username, scheduled.Username)
+	assert.Equal(t, arg.FromAccountID, scheduled.FromAccountID)
+	assert.Equal(t, arg.ToAccountID, scheduled.ToAccountID)
+	assert.Equal(t, arg.Amount, scheduled.Amount)
+	assert.Equal(t, arg.Currency, scheduled.Currency)
+	assert.Equal(t, arg.Schedule, scheduled.Schedule)
+	assert.WithinDuration(t, nextRunAt, scheduled.StartAt, time.Second)
+	assert.WithinDuration(t, nextRunAt, scheduled.NextRunAt.Time, time.Second)
+	assert.False(t, scheduled.EndAt.Valid)
+
+	return scheduled
+}
+
+func TestScheduledTransferCRUD(t *testing.T) {
+	from := createAccountInCurrency(t, "USD")
+	scheduled := createRandomScheduledTransfer(t, from, createAccountInCurrency(t, "USD"), time.Now().Add(time.Hour))
+
+	got, err := testQueries.GetScheduledTransfer(context.Background(), scheduled.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, scheduled.ID, got.ID)
+
+	endAt := time.Now().Add(30 * 24 * time.Hour)
+	updated, err := testQueries.UpdateScheduledTransfer(context.Background(), UpdateScheduledTransferParams{
+		ID:       scheduled.ID,
+		Amount:   scheduled.Amount + 1,
+		Schedule: "@weekly",
+		EndAt:    sql.NullTime{Time: endAt, Valid: true},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, scheduled.Amount+1, updated.Amount)
+	assert.Equal(t, "@weekly", updated.Schedule)
+	assert.WithinDuration(t, endAt, updated.EndAt.Time, time.Second)
+	assert.Equal(t, scheduled.NextRunAt, updated.NextRunAt)
+	// the new schedule starts over from the next run
+	assert.Equal(t, scheduled.NextRunAt.Time, updated.StartAt)
+
+	scheduledTransfers, err := testQueries.ListScheduledTransfersByUsername(context.Background(), ListScheduledTransfersByUsernameParams{
+		Username: from.Username,
+		Limit:    math.MaxInt32,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, scheduledTransfers, 1)
+
+	scheduledTransfers, err = testQueries.ListScheduledTransfersByUsernameAfter(context.Background(), ListScheduledTransfersByUsernameAfterParams{
+		Username: from.Username,
+		Cursor:   scheduled.ID,
+		Limit:    math.MaxInt32,
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, scheduledTransfers)
+
+	_, err = testQueries.CreateScheduledTransferRun(context.Background(), CreateScheduledTransferRunParams{
+		ScheduledTransferID: scheduled.ID,
+		Status:              ScheduledTransferRunStatusFailed,
+		Error:               ErrInsufficientFunds.Error(),
+	})
+	assert.NoError(t, err)
+
+	// the runs are deleted along with the scheduled transfer
+	err = testQueries.DeleteScheduledTransfer(context.Background(), scheduled.ID)
+	assert.NoError(t, err)
+	_, err = testQueries.GetScheduledTransfer(context.Background(), scheduled.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	runs, err := testQueries.ListScheduledTransferRuns(context.Background(), scheduled.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, runs)
+}
+
+// TestScheduledTransferTxRollback makes sure the transfer of a run is rolled back along with the run,
+// and a failed transfer is rolled back alone so the failed run can still be recorded
+func TestScheduledTransferTxRollback(t *testing.T) {
+	from := fundAccount(t, createAccountInCurrency(t, "USD"), 1000)
+	to := createAccountInCurrency(t, "USD")
+	// due before the scheduled transfers of the other tests so it's the one claimed
+	scheduled := createRandomScheduledTransfer(t, from, to, time.Now().AddDate(-100, 0, 0))
+	arg := TransferTxParams{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 100}
+
+	// the bookkeeping of the run fails after the transfer
+	errRecord := errors.New("cannot record run")
+	err := testStore.ScheduledTransferTx(context.Background(), func(q ScheduledTransferQuerier, claimed ScheduledTransfer) error {
+		assert.Equal(t, scheduled.ID, claimed.ID)
+		_, err := q.TransferTx(context.Background(), arg)
+		assert.NoError(t, err)
+		return errRecord
+	})
+	assert.ErrorIs(t, err, errRecord)
+
+	got, err := testQueries.GetAccount(context.Background(), from.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1000), got.Balance)
+
+	// the failed transfer doesn't abort the transaction of the run
+	err = testStore.ScheduledTransferTx(context.Background(), func(q ScheduledTransferQuerier, claimed ScheduledTransfer) error {
+		assert.Equal(t, scheduled.ID, claimed.ID)
+		_, err := q.TransferTx(context.Background(), TransferTxParams{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 2000})
+		assert.ErrorIs(t, err, ErrInsufficientFunds)
+
+		_, err = q.TransferTx(context.Background(), arg)
+		if err != nil {
+			return err
+		}
+		_, err = q.UpdateScheduledTransferNextRun(context.Background(), UpdateScheduledTransferNextRunParams{ID: claimed.ID})
+		return err
+	})
+	assert.NoError(t, err)
+
+	// the scheduled transfer is paid once
+	got, err = testQueries.GetAccount(context.Background(), from.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(900), got.Balance)
+	got, err = testQueries.GetAccount(context.Background(), to.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), got.Balance)
+}
+
+// errSkipClaim rolls back the claim of a scheduled transfer in tests
+var errSkipClaim = errors.New("skip claim")
+
+// TestScheduledTransferTxSkipLocked makes sure a scheduled transfer locked by a worker is skipped by the others
+func TestScheduledTransferTxSkipLocked(t *testing.T) {
+	scheduled := createRandomScheduledTransfer(t, createAccountInCurrency(t, "USD"), createAccountInCurrency(t, "USD"), time.Now().Add(-time.Hour))
+
+	locked := make(chan int64)
+	release := make(chan struct{})
+	errChan := make(chan error)
+	go func() {
+		errChan <- testStore.ScheduledTransferTx(context.Background(), func(q ScheduledTransferQuerier, claimed ScheduledTransfer) error {
+			locked <- claimed.ID
+			<-release
+			_, err := q.UpdateScheduledTransferNextRun(context.Background(), UpdateScheduledTransferNextRunParams{ID: claimed.ID})
+			return err
+		})
+	}()
+	first := <-locked
+
+	// the other worker claims another due scheduled transfer or finds nothing due
+	err := testStore.ScheduledTransferTx(context.Background(), func(q ScheduledTransferQuerier, claimed ScheduledTransfer) error {
+		assert.NotEqual(t, first, claimed.ID)
+		return errSkipClaim
+	})
+	if err != sql.ErrNoRows {
+		assert.ErrorIs(t, err, errSkipClaim)
+	}
+
+	close(release)
+	assert.NoError(t, <-errChan)
+
+	if first == scheduled.ID {
+		got, err := testQueries.GetScheduledTransfer(context.Background(), scheduled.ID)
+		assert.NoError(t, err)
+		assert.False(t, got.NextRunAt.Valid)
+	}
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// createRandomSession creates a random session by given user
+func createRandomSession(t *testing.T, user User) Session {
+	arg := CreateSessionParams{
+		ID:           uuid.New(),
+		Username:     user.Username,
+		RefreshToken: randomString(32),
+		UserAgent:    randomString(10),
+		ClientIp:     "127.0.0.1",
+		IsBlocked:    false,
+		ExpiresAt:    time.Now().Add(time.Hour),
+	}
+
+	session, err := testQueries.CreateSession(context.Background(), arg)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, session)
+
+	assert.Equal(t, arg.ID, session.ID)
+	assert.Equal(t, arg.Username, session.Username)
+	assert.Equal(t, arg.RefreshToken, session.RefreshToken)
+	assert.Equal(t, arg.UserAgent, session.UserAgent)
+	assert.Equal(t, arg.ClientIp, session.ClientIp)
+	assert.Equal(t, arg.IsBlocked, session.IsBlocked)
+	assert.WithinDuration(t, arg.ExpiresAt, session.ExpiresAt, time.Second)
+	assert.NotZero(t, session.CreatedAt)
+
+	return session
+}
+
+// TestCreateSession makes sure create a new session in db
+func TestCreateSession(t *testing.T) {
+	session := createRandomSession(t, createRandomUser(t))
+	assert.NotEmpty(t, session)
+}
+
+// TestGetSession makes sure get session by given ID
+func TestGetSession(t *testing.T) {
+	session1 := createRandomSession(t, createRandomUser(t))
+	session2, err := testQueries.GetSession(context.Background(), session1.ID)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, session2)
+
+	assert.Equal(t, session1.ID, session2.ID)
+	assert.Equal(t, session1.Username, session2.Username)
+	assert.Equal(t, session1.RefreshToken, session2.RefreshToken)
+	assert.Equal(t, session1.IsBlocked, session2.IsBlocked)
+	assert.Equal(t, session1.ExpiresAt, session2.ExpiresAt)
+	assert.Equal(t, session1.CreatedAt, session2.CreatedAt)
+}
+
+// TestBlockSession makes sure block session by given ID
+func TestBlockSession(t *testing.T) {
+	session1 := createRandomSession(t, createRandomUser(t))
+	session2, err := testQueries.BlockSession(context.Background(), session1.ID)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, session2)
+
+	assert.Equal(t, session1.ID, session2.ID)
+	assert.True(t, session2.IsBlocked)
+
+	session3, err := testQueries.BlockSession(context.Background(), uuid.New())
+	assert.EqualError(t, err, sql.ErrNoRows.Error())
+	assert.Empty(t, session3)
+}
@@ -3,13 +3,36 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+
+	"github.com/peienxie/go-bank/logging"
+	"go.uber.org/zap"
 )
 
+// ErrInsufficientFunds is returned when a transaction would drop the account
+// balance below its overdraft limit
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
 // Store provides all functions to execute db queries and transactions
 type Store interface {
 	Querier
 	TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
+	AuthorizeTransferTx(ctx context.Context, arg TransferTxParams) (Transfer, error)
+	SettleTransferTx(ctx context.Context, transferID int64) (TransferTxResult, error)
+	FailTransferTx(ctx context.Context, transferID int64) (Transfer, error)
+	FXTransferTx(ctx context.Context, arg FXTransferTxParams) (TransferTxResult, error)
+	ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (TransferTxResult, error)
+	PlaceHoldTx(ctx context.Context, arg PlaceHoldTxParams) (Hold, error)
+	CaptureHoldTx(ctx context.Context, arg CaptureHoldTxParams) (CaptureHoldTxResult, error)
+	ReleaseHoldTx(ctx context.Context, holdID int64) (Hold, error)
+	IdempotentTransferTx(ctx context.Context, arg IdempotentTransferTxParams) (IdempotentTransferTxResult, error)
+	DepositTx(ctx context.Context, arg DepositTxParams) (DepositTxResult, error)
+	WithdrawTx(ctx context.Context, arg WithdrawTxParams) (WithdrawTxResult, error)
+	ScheduledTransferTx(ctx context.Context, fn func(q ScheduledTransferQuerier, scheduled ScheduledTransfer) error) error
+	Reconcile(ctx context.Context) (ReconciliationReport, error)
+	Ping(ctx context.Context) error
+	GetSchemaVersion(ctx context.Context) (SchemaVersion, error)
 }
 
 // SQLStore provides all functions to execute SQL queries and transactions
@@ -22,21 +45,27 @@ type SQLStore struct {
 func NewSQLStore(db *sql.DB) *SQLStore {
 	return &SQLStore{
 		db:      db,
-		Queries: New(db),
+		Queries: New(tracedDBTX{db}),
 	}
 }
 
 // execTx executes the provided function `fn` within a database transaction
 func (s *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) error {
-	tx, err := s.db.BeginTx(ctx, nil)
+	return s.execTxWithOptions(ctx, nil, fn)
+}
+
+// execTxWithOptions executes the provided function `fn` within a database transaction of the options
+func (s *SQLStore) execTxWithOptions(ctx context.Context, opts *sql.TxOptions, fn func(*Queries) error) error {
+	tx, err := s.db.BeginTx(ctx, opts)
 	if err != nil {
 		return err
 	}
 
-	err = fn(New(tx))
+	err = fn(New(tracedDBTX{tx}))
 	if err != nil {
 		if rollbackErr := tx.Rollback(); rollbackErr != nil {
-			return fmt.Errorf("tx err: %v, rollback err: %v", err, rollbackErr)
+			logging.FromContext(ctx).Error("cannot roll back transaction", zap.Error(err), zap.NamedError("rollback_error", rollbackErr))
+			return fmt.Errorf("tx err: %w, rollback err: %v", err, rollbackErr)
 		}
 		return err
 	}
@@ -61,58 +90,145 @@ type TransferTxResult struct {
 }
 
 // TransferTx performs a money transfer from one account to the other account
-// It creates a tranfer record, accounts entries and update account's balance
+// It authorizes and settles the transfer in one transaction, so it creates a completed
+// tranfer record, accounts entries and update account's balance
+// It fails with ErrInsufficientFunds if the from account would be overdrawn
 func (s *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
 	var result TransferTxResult
 
 	err := s.execTx(ctx, func(q *Queries) error {
 		var err error
-		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams(arg))
-		if err != nil {
-			return err
-		}
+		result, err = transfer(ctx, q, arg)
+		return err
+	})
 
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount,
-		})
-		if err != nil {
-			return err
-		}
+	logTransfer(ctx, arg, result, err)
+	return result, err
+}
 
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
-		})
-		if err != nil {
-			return err
-		}
+// logTransfer logs the outcome of the transfer, it's called by every transaction which
+// performs a transfer once the transaction ends so a rolled back transfer is logged as failed
+func logTransfer(ctx context.Context, arg TransferTxParams, result TransferTxResult, err error) {
+	logger := logging.FromContext(ctx).With(
+		zap.Int64("from_account_id", arg.FromAccountID),
+		zap.Int64("to_account_id", arg.ToAccountID),
+		zap.Int64("amount", arg.Amount),
+	)
+	if err != nil {
+		logger.Warn("transfer failed", zap.Error(err))
+	} else {
+		logger.Info("transfer completed", zap.Int64("transfer_id", result.Transfer.ID))
+	}
+}
 
-		// always perform transaction with lowest id first
-		if arg.FromAccountID < arg.ToAccountID {
-			result.FromAccount, result.ToAccount, err = transferMoney(ctx, q, arg.FromAccountID, arg.ToAccountID, arg.Amount)
-		} else {
-			result.ToAccount, result.FromAccount, err = transferMoney(ctx, q, arg.ToAccountID, arg.FromAccountID, -arg.Amount)
-		}
+// transfer runs the steps of TransferTx with the queries bound to an existing transaction
+func transfer(ctx context.Context, q *Queries, arg TransferTxParams) (TransferTxResult, error) {
+	return executeTransfer(ctx, q, CreateTransferParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+		ToAmount:      arg.Amount,
+		ExchangeRate:  "1",
+	})
+}
 
-		return err
+// executeTransfer authorizes and settles the transfer at once, it debits Amount
+// from the from account and credits ToAmount to the to account
+func executeTransfer(ctx context.Context, q *Queries, arg CreateTransferParams) (TransferTxResult, error) {
+	transfer, err := authorizeTransfer(ctx, q, arg)
+	if err != nil {
+		return TransferTxResult{}, err
+	}
+	return settleTransfer(ctx, q, transfer)
+}
+
+// authorizeTransfer records the transfer as pending
+func authorizeTransfer(ctx context.Context, q *Queries, arg CreateTransferParams) (Transfer, error) {
+	transfer, err := q.CreateTransfer(ctx, arg)
+	if err != nil {
+		return Transfer{}, err
+	}
+
+	_, err = q.CreateTransferStatusChange(ctx, CreateTransferStatusChangeParams{
+		TransferID: transfer.ID,
+		Status:     transfer.Status,
 	})
+	return transfer, err
+}
 
-	return result, err
+// settleTransfer moves the money of the pending transfer between the accounts,
+// creates its entries and marks the transfer completed
+func settleTransfer(ctx context.Context, q *Queries, transfer Transfer) (result TransferTxResult, err error) {
+	// always perform transaction with lowest id first
+	if transfer.FromAccountID < transfer.ToAccountID {
+		result.FromAccount, result.ToAccount, err = transferMoney(ctx, q, transfer.FromAccountID, -transfer.Amount, transfer.ToAccountID, transfer.ToAmount)
+	} else {
+		result.ToAccount, result.FromAccount, err = transferMoney(ctx, q, transfer.ToAccountID, transfer.ToAmount, transfer.FromAccountID, -transfer.Amount)
+	}
+	if err != nil {
+		return
+	}
+
+	// the entries are created once the account rows are locked by the balance update,
+	// so they record the balance after them
+	transferID := sql.NullInt64{Int64: transfer.ID, Valid: true}
+	result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:    transfer.FromAccountID,
+		Amount:       -transfer.Amount,
+		TransferID:   transferID,
+		Type:         EntryTypeTransfer,
+		BalanceAfter: result.FromAccount.Balance,
+	})
+	if err != nil {
+		return
+	}
+
+	result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:    transfer.ToAccountID,
+		Amount:       transfer.ToAmount,
+		TransferID:   transferID,
+		Type:         EntryTypeTransfer,
+		BalanceAfter: result.ToAccount.Balance,
+	})
+	if err != nil {
+		return
+	}
+
+	err = checkSufficientFunds(ctx, q, result.FromAccount)
+	if err != nil {
+		return
+	}
+
+	result.Transfer, err = transitionTransfer(ctx, q, transfer, TransferStatusCompleted)
+	return
 }
 
-// transferMoney transfer given amount of money from account to the other account
-func transferMoney(ctx context.Context, q *Queries, from_id, to_id, amount int64) (from, to Account, err error) {
-	from, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
-		ID:     from_id,
-		Amount: -amount,
+// transferMoney adds the amounts to the balance of the two accounts in the given order
+func transferMoney(ctx context.Context, q *Queries, id1, amount1, id2, amount2 int64) (account1, account2 Account, err error) {
+	account1, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+		ID:     id1,
+		Amount: amount1,
 	})
 	if err != nil {
 		return
 	}
-	to, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
-		ID:     to_id,
-		Amount: amount,
+	account2, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+		ID:     id2,
+		Amount: amount2,
 	})
 	return
 }
+
+// checkSufficientFunds makes sure the available balance of the account, which is
+// the balance minus the active holds, is not below its overdraft limit.
+// The account row is locked by the balance update, so the check is safe within the transaction
+func checkSufficientFunds(ctx context.Context, q *Queries, account Account) error {
+	heldAmount, err := q.GetAccountHeldAmount(ctx, account.ID)
+	if err != nil {
+		return err
+	}
+	if account.Balance-heldAmount < -account.OverdraftLimit {
+		return ErrInsufficientFunds
+	}
+	return nil
+}
@@ -5,17 +5,21 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/peienxie/go-bank/logging"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 // TestTransferTx makes sure money transfer from one account to the other account
 func TestTransferTx(t *testing.T) {
-	fromAccount := createRandomAccount(t)
+	n := 10
+	amount := int64(10)
+
+	fromAccount := fundAccount(t, createRandomAccount(t), amount*int64(n))
 	toAccount := createRandomAccount(t)
 	fmt.Printf("before: from: %d, to: %d\n", fromAccount.Balance, toAccount.Balance)
 
-	n := 10
-	amount := int64(10)
 	errChan := make(chan error)
 	resultChan := make(chan TransferTxResult)
 
@@ -47,6 +51,7 @@ func TestTransferTx(t *testing.T) {
 		assert.Equal(t, result.Transfer.ToAccountID, toAccount.ID)
 		assert.Equal(t, result.Transfer.Amount, amount)
 		assert.NotZero(t, result.Transfer.CreatedAt)
+		assert.Equal(t, TransferStatusCompleted, result.Transfer.Status)
 		_, err = testStore.GetTransfer(context.Background(), result.Transfer.ID)
 		assert.NoError(t, err)
 
@@ -54,6 +59,8 @@ func TestTransferTx(t *testing.T) {
 		assert.NotZero(t, result.FromEntry.ID)
 		assert.Equal(t, result.FromEntry.AccountID, fromAccount.ID)
 		assert.Equal(t, result.FromEntry.Amount, -amount)
+		assert.Equal(t, result.Transfer.ID, result.FromEntry.TransferID.Int64)
+		assert.Equal(t, EntryTypeTransfer, result.FromEntry.Type)
 		assert.NotZero(t, result.FromEntry.CreatedAt)
 		_, err = testStore.GetEntry(context.Background(), result.FromEntry.ID)
 		assert.NoError(t, err)
@@ -62,6 +69,8 @@ func TestTransferTx(t *testing.T) {
 		assert.NotZero(t, result.ToEntry.ID)
 		assert.Equal(t, result.ToEntry.AccountID, toAccount.ID)
 		assert.Equal(t, result.ToEntry.Amount, amount)
+		assert.Equal(t, result.Transfer.ID, result.ToEntry.TransferID.Int64)
+		assert.Equal(t, EntryTypeTransfer, result.ToEntry.Type)
 		assert.NotZero(t, result.ToEntry.CreatedAt)
 		_, err = testStore.GetEntry(context.Background(), result.ToEntry.ID)
 		assert.NoError(t, err)
@@ -72,6 +81,10 @@ func TestTransferTx(t *testing.T) {
 		assert.NotEmpty(t, result.ToAccount)
 		assert.NotZero(t, result.ToAccount.ID)
 
+		// the entries record the balances left by their own transfer
+		assert.Equal(t, result.FromAccount.Balance, result.FromEntry.BalanceAfter)
+		assert.Equal(t, result.ToAccount.Balance, result.ToEntry.BalanceAfter)
+
 		// check balance
 		diff1 := fromAccount.Balance - result.FromAccount.Balance
 		diff2 := result.ToAccount.Balance - toAccount.Balance
@@ -99,11 +112,11 @@ func TestTransferTx(t *testing.T) {
 
 // TestTransferTxDeadlock makes sure deadlock does not occur
 func TestTransferTxDeadlock(t *testing.T) {
-	fromAccount := createRandomAccount(t)
-	toAccount := createRandomAccount(t)
-
 	n := 10
 	amount := int64(10)
+
+	fromAccount := fundAccount(t, createRandomAccount(t), amount*int64(n))
+	toAccount := fundAccount(t, createRandomAccount(t), amount*int64(n))
 	errChan := make(chan error)
 
 	for i := 0; i < n; i++ {
@@ -130,3 +143,87 @@ func TestTransferTxDeadlock(t *testing.T) {
 		assert.NoError(t, err)
 	}
 }
+
+// TestTransferTxInsufficientFunds makes sure transfer is rolled back when the balance is not enough
+func TestTransferTxInsufficientFunds(t *testing.T) {
+	fromAccount := createRandomAccount(t)
+	toAccount := createRandomAccount(t)
+
+	_, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        fromAccount.Balance + 1,
+	})
+	assert.Error(t, err)
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+
+	updatedFromAccount, err := testStore.GetAccount(context.Background(), fromAccount.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, fromAccount.Balance, updatedFromAccount.Balance)
+
+	updatedToAccount, err := testStore.GetAccount(context.Background(), toAccount.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, toAccount.Balance, updatedToAccount.Balance)
+}
+
+// TestTransferTxLogs makes sure a failed transfer is logged with the logger of the context
+func TestTransferTxLogs(t *testing.T) {
+	fromAccount := createRandomAccount(t)
+	toAccount := createRandomAccount(t)
+
+	core, logs := observer.New(zap.InfoLevel)
+	ctx := logging.NewContext(context.Background(), zap.New(core).With(zap.String("request_id", "test-request-id")))
+
+	_, err := testStore.TransferTx(ctx, TransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        fromAccount.Balance + 1,
+	})
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+
+	entries := logs.FilterMessage("transfer failed").All()
+	assert.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "test-request-id", fields["request_id"])
+	assert.Equal(t, fromAccount.ID, fields["from_account_id"])
+	assert.Equal(t, ErrInsufficientFunds.Error(), fields["error"])
+}
+
+// TestTransferTxOverdraftLimit makes sure the balance can go negative up to the overdraft limit
+func TestTransferTxOverdraftLimit(t *testing.T) {
+	fromAccount := createRandomAccount(t)
+	toAccount := createRandomAccount(t)
+
+	limit := int64(100)
+	fromAccount, err := testStore.UpdateAccountOverdraftLimit(context.Background(), UpdateAccountOverdraftLimitParams{
+		ID:             fromAccount.ID,
+		OverdraftLimit: limit,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, limit, fromAccount.OverdraftLimit)
+
+	result, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        fromAccount.Balance + limit,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, -limit, result.FromAccount.Balance)
+
+	_, err = testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        1,
+	})
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+}
+
+// fundAccount adds the amount of money to the account balance
+func fundAccount(t *testing.T, account Account, amount int64) Account {
+	account, err := testStore.AddAccountBalance(context.Background(), AddAccountBalanceParams{
+		ID:     account.ID,
+		Amount: amount,
+	})
+	assert.NoError(t, err)
+	return account
+}
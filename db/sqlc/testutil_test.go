@@ -11,7 +11,7 @@ func init() {
 
 // randomInt generates a random number in [min, max]
 func randomInt(min, max int64) int64 {
-	return rand.Int63n(max - min + 1)
+	return min + rand.Int63n(max-min+1)
 }
 
 const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
@@ -30,6 +30,11 @@ func randomUsername() string {
 	return randomString(10)
 }
 
+// randomEmail returns a random email address
+func randomEmail() string {
+	return randomString(6) + "@email.com"
+}
+
 // randomMoney generates a random amount of money
 func randomMoney() int64 {
 	return randomInt(0, 1000)
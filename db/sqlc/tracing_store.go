@@ -0,0 +1,471 @@
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracingStore decorates a Store to create a span for every method,
+// the queries it runs are traced as the child spans
+type TracingStore struct {
+	store Store
+}
+
+var _ Store = (*TracingStore)(nil)
+
+// NewTracingStore creates a Store tracing the calls to store
+func NewTracingStore(store Store) *TracingStore {
+	return &TracingStore{store: store}
+}
+
+// startStoreSpan starts the span of the Store method
+func startStoreSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, "Store."+method, trace.WithSpanKind(trace.SpanKindInternal))
+}
+
+func (s *TracingStore) AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (_ Account, err error) {
+	ctx, span := startStoreSpan(ctx, "AddAccountBalance")
+	defer func() { endSpan(span, err) }()
+	return s.store.AddAccountBalance(ctx, arg)
+}
+
+func (s *TracingStore) AuthorizeTransferTx(ctx context.Context, arg TransferTxParams) (_ Transfer, err error) {
+	ctx, span := startStoreSpan(ctx, "AuthorizeTransferTx")
+	defer func() { endSpan(span, err) }()
+	return s.store.AuthorizeTransferTx(ctx, arg)
+}
+
+func (s *TracingStore) BlockSession(ctx context.Context, id uuid.UUID) (_ Session, err error) {
+	ctx, span := startStoreSpan(ctx, "BlockSession")
+	defer func() { endSpan(span, err) }()
+	return s.store.BlockSession(ctx, id)
+}
+
+func (s *TracingStore) CaptureHold(ctx context.Context, arg CaptureHoldParams) (_ Hold, err error) {
+	ctx, span := startStoreSpan(ctx, "CaptureHold")
+	defer func() { endSpan(span, err) }()
+	return s.store.CaptureHold(ctx, arg)
+}
+
+func (s *TracingStore) CaptureHoldTx(ctx context.Context, arg CaptureHoldTxParams) (_ CaptureHoldTxResult, err error) {
+	ctx, span := startStoreSpan(ctx, "CaptureHoldTx")
+	defer func() { endSpan(span, err) }()
+	return s.store.CaptureHoldTx(ctx, arg)
+}
+
+func (s *TracingStore) CreateAccount(ctx context.Context, arg CreateAccountParams) (_ Account, err error) {
+	ctx, span := startStoreSpan(ctx, "CreateAccount")
+	defer func() { endSpan(span, err) }()
+	return s.store.CreateAccount(ctx, arg)
+}
+
+func (s *TracingStore) CreateEntry(ctx context.Context, arg CreateEntryParams) (_ Entry, err error) {
+	ctx, span := startStoreSpan(ctx, "CreateEntry")
+	defer func() { endSpan(span, err) }()
+	return s.store.CreateEntry(ctx, arg)
+}
+
+func (s *TracingStore) CreateExchangeRate(ctx context.Context, arg CreateExchangeRateParams) (_ ExchangeRate, err error) {
+	ctx, span := startStoreSpan(ctx, "CreateExchangeRate")
+	defer func() { endSpan(span, err) }()
+	return s.store.CreateExchangeRate(ctx, arg)
+}
+
+func (s *TracingStore) CreateHold(ctx context.Context, arg CreateHoldParams) (_ Hold, err error) {
+	ctx, span := startStoreSpan(ctx, "CreateHold")
+	defer func() { endSpan(span, err) }()
+	return s.store.CreateHold(ctx, arg)
+}
+
+func (s *TracingStore) CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) (_ IdempotencyKey, err error) {
+	ctx, span := startStoreSpan(ctx, "CreateIdempotencyKey")
+	defer func() { endSpan(span, err) }()
+	return s.store.CreateIdempotencyKey(ctx, arg)
+}
+
+func (s *TracingStore) CreateScheduledTransfer(ctx context.Context, arg CreateScheduledTransferParams) (_ ScheduledTransfer, err error) {
+	ctx, span := startStoreSpan(ctx, "CreateScheduledTransfer")
+	defer func() { endSpan(span, err) }()
+	return s.store.CreateScheduledTransfer(ctx, arg)
+}
+
+func (s *TracingStore) CreateScheduledTransferRun(ctx context.Context, arg CreateScheduledTransferRunParams) (_ ScheduledTransferRun, err error) {
+	ctx, span := startStoreSpan(ctx, "CreateScheduledTransferRun")
+	defer func() { endSpan(span, err) }()
+	return s.store.CreateScheduledTransferRun(ctx, arg)
+}
+
+func (s *TracingStore) CreateSession(ctx context.Context, arg CreateSessionParams) (_ Session, err error) {
+	ctx, span := startStoreSpan(ctx, "CreateSession")
+	defer func() { endSpan(span, err) }()
+	return s.store.CreateSession(ctx, arg)
+}
+
+func (s *TracingStore) CreateTransfer(ctx context.Context, arg CreateTransferParams) (_ Transfer, err error) {
+	ctx, span := startStoreSpan(ctx, "CreateTransfer")
+	defer func() { endSpan(span, err) }()
+	return s.store.CreateTransfer(ctx, arg)
+}
+
+func (s *TracingStore) CreateTransferStatusChange(ctx context.Context, arg CreateTransferStatusChangeParams) (_ TransferStatusChange, err error) {
+	ctx, span := startStoreSpan(ctx, "CreateTransferStatusChange")
+	defer func() { endSpan(span, err) }()
+	return s.store.CreateTransferStatusChange(ctx, arg)
+}
+
+func (s *TracingStore) CreateUser(ctx context.Context, arg CreateUserParams) (_ User, err error) {
+	ctx, span := startStoreSpan(ctx, "CreateUser")
+	defer func() { endSpan(span, err) }()
+	return s.store.CreateUser(ctx, arg)
+}
+
+func (s *TracingStore) DeleteAccount(ctx context.Context, id int64) (err error) {
+	ctx, span := startStoreSpan(ctx, "DeleteAccount")
+	defer func() { endSpan(span, err) }()
+	return s.store.DeleteAccount(ctx, id)
+}
+
+func (s *TracingStore) DeleteEntry(ctx context.Context, id int64) (err error) {
+	ctx, span := startStoreSpan(ctx, "DeleteEntry")
+	defer func() { endSpan(span, err) }()
+	return s.store.DeleteEntry(ctx, id)
+}
+
+func (s *TracingStore) DeleteScheduledTransfer(ctx context.Context, id int64) (err error) {
+	ctx, span := startStoreSpan(ctx, "DeleteScheduledTransfer")
+	defer func() { endSpan(span, err) }()
+	return s.store.DeleteScheduledTransfer(ctx, id)
+}
+
+func (s *TracingStore) DeleteTransfer(ctx context.Context, id int64) (err error) {
+	ctx, span := startStoreSpan(ctx, "DeleteTransfer")
+	defer func() { endSpan(span, err) }()
+	return s.store.DeleteTransfer(ctx, id)
+}
+
+func (s *TracingStore) DepositTx(ctx context.Context, arg DepositTxParams) (_ DepositTxResult, err error) {
+	ctx, span := startStoreSpan(ctx, "DepositTx")
+	defer func() { endSpan(span, err) }()
+	return s.store.DepositTx(ctx, arg)
+}
+
+func (s *TracingStore) ExpireHolds(ctx context.Context) (_ int64, err error) {
+	ctx, span := startStoreSpan(ctx, "ExpireHolds")
+	defer func() { endSpan(span, err) }()
+	return s.store.ExpireHolds(ctx)
+}
+
+func (s *TracingStore) FXTransferTx(ctx context.Context, arg FXTransferTxParams) (_ TransferTxResult, err error) {
+	ctx, span := startStoreSpan(ctx, "FXTransferTx")
+	defer func() { endSpan(span, err) }()
+	return s.store.FXTransferTx(ctx, arg)
+}
+
+func (s *TracingStore) FailTransferTx(ctx context.Context, transferID int64) (_ Transfer, err error) {
+	ctx, span := startStoreSpan(ctx, "FailTransferTx")
+	defer func() { endSpan(span, err) }()
+	return s.store.FailTransferTx(ctx, transferID)
+}
+
+func (s *TracingStore) GetAccount(ctx context.Context, id int64) (_ Account, err error) {
+	ctx, span := startStoreSpan(ctx, "GetAccount")
+	defer func() { endSpan(span, err) }()
+	return s.store.GetAccount(ctx, id)
+}
+
+func (s *TracingStore) GetAccountForUpdate(ctx context.Context, id int64) (_ Account, err error) {
+	ctx, span := startStoreSpan(ctx, "GetAccountForUpdate")
+	defer func() { endSpan(span, err) }()
+	return s.store.GetAccountForUpdate(ctx, id)
+}
+
+func (s *TracingStore) GetAccountHeldAmount(ctx context.Context, accountID int64) (_ int64, err error) {
+	ctx, span := startStoreSpan(ctx, "GetAccountHeldAmount")
+	defer func() { endSpan(span, err) }()
+	return s.store.GetAccountHeldAmount(ctx, accountID)
+}
+
+func (s *TracingStore) GetCurrency(ctx context.Context, code string) (_ Currency, err error) {
+	ctx, span := startStoreSpan(ctx, "GetCurrency")
+	defer func() { endSpan(span, err) }()
+	return s.store.GetCurrency(ctx, code)
+}
+
+func (s *TracingStore) GetDueScheduledTransferForUpdate(ctx context.Context) (_ ScheduledTransfer, err error) {
+	ctx, span := startStoreSpan(ctx, "GetDueScheduledTransferForUpdate")
+	defer func() { endSpan(span, err) }()
+	return s.store.GetDueScheduledTransferForUpdate(ctx)
+}
+
+func (s *TracingStore) GetEntry(ctx context.Context, id int64) (_ Entry, err error) {
+	ctx, span := startStoreSpan(ctx, "GetEntry")
+	defer func() { endSpan(span, err) }()
+	return s.store.GetEntry(ctx, id)
+}
+
+func (s *TracingStore) GetExchangeRate(ctx context.Context, arg GetExchangeRateParams) (_ ExchangeRate, err error) {
+	ctx, span := startStoreSpan(ctx, "GetExchangeRate")
+	defer func() { endSpan(span, err) }()
+	return s.store.GetExchangeRate(ctx, arg)
+}
+
+func (s *TracingStore) GetHold(ctx context.Context, id int64) (_ Hold, err error) {
+	ctx, span := startStoreSpan(ctx, "GetHold")
+	defer func() { endSpan(span, err) }()
+	return s.store.GetHold(ctx, id)
+}
+
+func (s *TracingStore) GetHoldForUpdate(ctx context.Context, id int64) (_ Hold, err error) {
+	ctx, span := startStoreSpan(ctx, "GetHoldForUpdate")
+	defer func() { endSpan(span, err) }()
+	return s.store.GetHoldForUpdate(ctx, id)
+}
+
+func (s *TracingStore) GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (_ IdempotencyKey, err error) {
+	ctx, span := startStoreSpan(ctx, "GetIdempotencyKey")
+	defer func() { endSpan(span, err) }()
+	return s.store.GetIdempotencyKey(ctx, arg)
+}
+
+func (s *TracingStore) GetScheduledTransfer(ctx context.Context, id int64) (_ ScheduledTransfer, err error) {
+	ctx, span := startStoreSpan(ctx, "GetScheduledTransfer")
+	defer func() { endSpan(span, err) }()
+	return s.store.GetScheduledTransfer(ctx, id)
+}
+
+func (s *TracingStore) GetSchemaVersion(ctx context.Context) (_ SchemaVersion, err error) {
+	ctx, span := startStoreSpan(ctx, "GetSchemaVersion")
+	defer func() { endSpan(span, err) }()
+	return s.store.GetSchemaVersion(ctx)
+}
+
+func (s *TracingStore) GetSession(ctx context.Context, id uuid.UUID) (_ Session, err error) {
+	ctx, span := startStoreSpan(ctx, "GetSession")
+	defer func() { endSpan(span, err) }()
+	return s.store.GetSession(ctx, id)
+}
+
+func (s *TracingStore) GetTransfer(ctx context.Context, id int64) (_ Transfer, err error) {
+	ctx, span := startStoreSpan(ctx, "GetTransfer")
+	defer func() { endSpan(span, err) }()
+	return s.store.GetTransfer(ctx, id)
+}
+
+func (s *TracingStore) GetTransferForUpdate(ctx context.Context, id int64) (_ Transfer, err error) {
+	ctx, span := startStoreSpan(ctx, "GetTransferForUpdate")
+	defer func() { endSpan(span, err) }()
+	return s.store.GetTransferForUpdate(ctx, id)
+}
+
+func (s *TracingStore) GetUser(ctx context.Context, username string) (_ User, err error) {
+	ctx, span := startStoreSpan(ctx, "GetUser")
+	defer func() { endSpan(span, err) }()
+	return s.store.GetUser(ctx, username)
+}
+
+func (s *TracingStore) IdempotentTransferTx(ctx context.Context, arg IdempotentTransferTxParams) (_ IdempotentTransferTxResult, err error) {
+	ctx, span := startStoreSpan(ctx, "IdempotentTransferTx")
+	defer func() { endSpan(span, err) }()
+	return s.store.IdempotentTransferTx(ctx, arg)
+}
+
+func (s *TracingStore) ListAccountEntries(ctx context.Context, arg ListAccountEntriesParams) (_ []ListAccountEntriesRow, err error) {
+	ctx, span := startStoreSpan(ctx, "ListAccountEntries")
+	defer func() { endSpan(span, err) }()
+	return s.store.ListAccountEntries(ctx, arg)
+}
+
+func (s *TracingStore) ListAccountEntriesAfter(ctx context.Context, arg ListAccountEntriesAfterParams) (_ []ListAccountEntriesAfterRow, err error) {
+	ctx, span := startStoreSpan(ctx, "ListAccountEntriesAfter")
+	defer func() { endSpan(span, err) }()
+	return s.store.ListAccountEntriesAfter(ctx, arg)
+}
+
+func (s *TracingStore) ListAccounts(ctx context.Context, arg ListAccountsParams) (_ []Account, err error) {
+	ctx, span := startStoreSpan(ctx, "ListAccounts")
+	defer func() { endSpan(span, err) }()
+	return s.store.ListAccounts(ctx, arg)
+}
+
+func (s *TracingStore) ListAccountsByUsername(ctx context.Context, arg ListAccountsByUsernameParams) (_ []Account, err error) {
+	ctx, span := startStoreSpan(ctx, "ListAccountsByUsername")
+	defer func() { endSpan(span, err) }()
+	return s.store.ListAccountsByUsername(ctx, arg)
+}
+
+func (s *TracingStore) ListAccountsByUsernameAfter(ctx context.Context, arg ListAccountsByUsernameAfterParams) (_ []Account, err error) {
+	ctx, span := startStoreSpan(ctx, "ListAccountsByUsernameAfter")
+	defer func() { endSpan(span, err) }()
+	return s.store.ListAccountsByUsernameAfter(ctx, arg)
+}
+
+func (s *TracingStore) ListBalanceMismatches(ctx context.Context) (_ []ListBalanceMismatchesRow, err error) {
+	ctx, span := startStoreSpan(ctx, "ListBalanceMismatches")
+	defer func() { endSpan(span, err) }()
+	return s.store.ListBalanceMismatches(ctx)
+}
+
+func (s *TracingStore) ListCurrencies(ctx context.Context) (_ []Currency, err error) {
+	ctx, span := startStoreSpan(ctx, "ListCurrencies")
+	defer func() { endSpan(span, err) }()
+	return s.store.ListCurrencies(ctx)
+}
+
+func (s *TracingStore) ListEntries(ctx context.Context, arg ListEntriesParams) (_ []Entry, err error) {
+	ctx, span := startStoreSpan(ctx, "ListEntries")
+	defer func() { endSpan(span, err) }()
+	return s.store.ListEntries(ctx, arg)
+}
+
+func (s *TracingStore) ListScheduledTransferRuns(ctx context.Context, scheduledTransferID int64) (_ []ScheduledTransferRun, err error) {
+	ctx, span := startStoreSpan(ctx, "ListScheduledTransferRuns")
+	defer func() { endSpan(span, err) }()
+	return s.store.ListScheduledTransferRuns(ctx, scheduledTransferID)
+}
+
+func (s *TracingStore) ListScheduledTransfersByUsername(ctx context.Context, arg ListScheduledTransfersByUsernameParams) (_ []ScheduledTransfer, err error) {
+	ctx, span := startStoreSpan(ctx, "ListScheduledTransfersByUsername")
+	defer func() { endSpan(span, err) }()
+	return s.store.ListScheduledTransfersByUsername(ctx, arg)
+}
+
+func (s *TracingStore) ListScheduledTransfersByUsernameAfter(ctx context.Context, arg ListScheduledTransfersByUsernameAfterParams) (_ []ScheduledTransfer, err error) {
+	ctx, span := startStoreSpan(ctx, "ListScheduledTransfersByUsernameAfter")
+	defer func() { endSpan(span, err) }()
+	return s.store.ListScheduledTransfersByUsernameAfter(ctx, arg)
+}
+
+func (s *TracingStore) ListTransferStatusChanges(ctx context.Context, transferID int64) (_ []TransferStatusChange, err error) {
+	ctx, span := startStoreSpan(ctx, "ListTransferStatusChanges")
+	defer func() { endSpan(span, err) }()
+	return s.store.ListTransferStatusChanges(ctx, transferID)
+}
+
+func (s *TracingStore) ListTransfers(ctx context.Context, arg ListTransfersParams) (_ []Transfer, err error) {
+	ctx, span := startStoreSpan(ctx, "ListTransfers")
+	defer func() { endSpan(span, err) }()
+	return s.store.ListTransfers(ctx, arg)
+}
+
+func (s *TracingStore) ListTransfersByAccount(ctx context.Context, arg ListTransfersByAccountParams) (_ []Transfer, err error) {
+	ctx, span := startStoreSpan(ctx, "ListTransfersByAccount")
+	defer func() { endSpan(span, err) }()
+	return s.store.ListTransfersByAccount(ctx, arg)
+}
+
+func (s *TracingStore) ListTransfersByAccountAfter(ctx context.Context, arg ListTransfersByAccountAfterParams) (_ []Transfer, err error) {
+	ctx, span := startStoreSpan(ctx, "ListTransfersByAccountAfter")
+	defer func() { endSpan(span, err) }()
+	return s.store.ListTransfersByAccountAfter(ctx, arg)
+}
+
+func (s *TracingStore) ListUnmatchedTransfers(ctx context.Context) (_ []ListUnmatchedTransfersRow, err error) {
+	ctx, span := startStoreSpan(ctx, "ListUnmatchedTransfers")
+	defer func() { endSpan(span, err) }()
+	return s.store.ListUnmatchedTransfers(ctx)
+}
+
+func (s *TracingStore) LockIdempotencyKey(ctx context.Context, arg LockIdempotencyKeyParams) (err error) {
+	ctx, span := startStoreSpan(ctx, "LockIdempotencyKey")
+	defer func() { endSpan(span, err) }()
+	return s.store.LockIdempotencyKey(ctx, arg)
+}
+
+func (s *TracingStore) Ping(ctx context.Context) (err error) {
+	ctx, span := startStoreSpan(ctx, "Ping")
+	defer func() { endSpan(span, err) }()
+	return s.store.Ping(ctx)
+}
+
+func (s *TracingStore) PlaceHoldTx(ctx context.Context, arg PlaceHoldTxParams) (_ Hold, err error) {
+	ctx, span := startStoreSpan(ctx, "PlaceHoldTx")
+	defer func() { endSpan(span, err) }()
+	return s.store.PlaceHoldTx(ctx, arg)
+}
+
+func (s *TracingStore) Reconcile(ctx context.Context) (_ ReconciliationReport, err error) {
+	ctx, span := startStoreSpan(ctx, "Reconcile")
+	defer func() { endSpan(span, err) }()
+	return s.store.Reconcile(ctx)
+}
+
+func (s *TracingStore) ReleaseHoldTx(ctx context.Context, holdID int64) (_ Hold, err error) {
+	ctx, span := startStoreSpan(ctx, "ReleaseHoldTx")
+	defer func() { endSpan(span, err) }()
+	return s.store.ReleaseHoldTx(ctx, holdID)
+}
+
+func (s *TracingStore) ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (_ TransferTxResult, err error) {
+	ctx, span := startStoreSpan(ctx, "ReverseTransferTx")
+	defer func() { endSpan(span, err) }()
+	return s.store.ReverseTransferTx(ctx, arg)
+}
+
+func (s *TracingStore) ScheduledTransferTx(ctx context.Context, fn func(q ScheduledTransferQuerier, scheduled ScheduledTransfer) error) (err error) {
+	ctx, span := startStoreSpan(ctx, "ScheduledTransferTx")
+	defer func() { endSpan(span, err) }()
+	return s.store.ScheduledTransferTx(ctx, fn)
+}
+
+func (s *TracingStore) SettleTransferTx(ctx context.Context, transferID int64) (_ TransferTxResult, err error) {
+	ctx, span := startStoreSpan(ctx, "SettleTransferTx")
+	defer func() { endSpan(span, err) }()
+	return s.store.SettleTransferTx(ctx, transferID)
+}
+
+func (s *TracingStore) TransferTx(ctx context.Context, arg TransferTxParams) (_ TransferTxResult, err error) {
+	ctx, span := startStoreSpan(ctx, "TransferTx")
+	defer func() { endSpan(span, err) }()
+	return s.store.TransferTx(ctx, arg)
+}
+
+func (s *TracingStore) UpdateAccountBalance(ctx context.Context, arg UpdateAccountBalanceParams) (_ Account, err error) {
+	ctx, span := startStoreSpan(ctx, "UpdateAccountBalance")
+	defer func() { endSpan(span, err) }()
+	return s.store.UpdateAccountBalance(ctx, arg)
+}
+
+func (s *TracingStore) UpdateAccountOverdraftLimit(ctx context.Context, arg UpdateAccountOverdraftLimitParams) (_ Account, err error) {
+	ctx, span := startStoreSpan(ctx, "UpdateAccountOverdraftLimit")
+	defer func() { endSpan(span, err) }()
+	return s.store.UpdateAccountOverdraftLimit(ctx, arg)
+}
+
+func (s *TracingStore) UpdateHoldStatus(ctx context.Context, arg UpdateHoldStatusParams) (_ Hold, err error) {
+	ctx, span := startStoreSpan(ctx, "UpdateHoldStatus")
+	defer func() { endSpan(span, err) }()
+	return s.store.UpdateHoldStatus(ctx, arg)
+}
+
+func (s *TracingStore) UpdateScheduledTransfer(ctx context.Context, arg UpdateScheduledTransferParams) (_ ScheduledTransfer, err error) {
+	ctx, span := startStoreSpan(ctx, "UpdateScheduledTransfer")
+	defer func() { endSpan(span, err) }()
+	return s.store.UpdateScheduledTransfer(ctx, arg)
+}
+
+func (s *TracingStore) UpdateScheduledTransferNextRun(ctx context.Context, arg UpdateScheduledTransferNextRunParams) (_ ScheduledTransfer, err error) {
+	ctx, span := startStoreSpan(ctx, "UpdateScheduledTransferNextRun")
+	defer func() { endSpan(span, err) }()
+	return s.store.UpdateScheduledTransferNextRun(ctx, arg)
+}
+
+func (s *TracingStore) UpdateTransferReversal(ctx context.Context, arg UpdateTransferReversalParams) (_ Transfer, err error) {
+	ctx, span := startStoreSpan(ctx, "UpdateTransferReversal")
+	defer func() { endSpan(span, err) }()
+	return s.store.UpdateTransferReversal(ctx, arg)
+}
+
+func (s *TracingStore) UpdateTransferStatus(ctx context.Context, arg UpdateTransferStatusParams) (_ Transfer, err error) {
+	ctx, span := startStoreSpan(ctx, "UpdateTransferStatus")
+	defer func() { endSpan(span, err) }()
+	return s.store.UpdateTransferStatus(ctx, arg)
+}
+
+func (s *TracingStore) WithdrawTx(ctx context.Context, arg WithdrawTxParams) (_ WithdrawTxResult, err error) {
+	ctx, span := startStoreSpan(ctx, "WithdrawTx")
+	defer func() { endSpan(span, err) }()
+	return s.store.WithdrawTx(ctx, arg)
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans sets a global tracer provider recording the spans in memory for the test
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return exporter
+}
+
+func TestTracingStore(t *testing.T) {
+	exporter := recordSpans(t)
+
+	store := NewTracingStore(stubStore{})
+	_, err := store.TransferTx(context.Background(), TransferTxParams{})
+	assert.NoError(t, err)
+
+	store = NewTracingStore(stubStore{err: errors.New("failed")})
+	_, err = store.TransferTx(context.Background(), TransferTxParams{})
+	assert.Error(t, err)
+
+	store = NewTracingStore(stubStore{err: sql.ErrNoRows})
+	_, err = store.GetAccount(context.Background(), 1)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 3)
+	assert.Equal(t, "Store.TransferTx", spans[0].Name)
+	assert.Equal(t, codes.Unset, spans[0].Status.Code)
+	assert.Equal(t, "Store.TransferTx", spans[1].Name)
+	assert.Equal(t, codes.Error, spans[1].Status.Code)
+	assert.Equal(t, "Store.GetAccount", spans[2].Name)
+	assert.Equal(t, codes.Unset, spans[2].Status.Code)
+}
+
+// TestTransferTxSpans makes sure the queries of TransferTx are traced as children of its span
+func TestTransferTxSpans(t *testing.T) {
+	fromAccount := fundAccount(t, createRandomAccount(t), 10)
+	toAccount := createRandomAccount(t)
+	exporter := recordSpans(t)
+
+	_, err := NewTracingStore(testStore).TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        10,
+	})
+	assert.NoError(t, err)
+
+	spans := exporter.GetSpans()
+	root := spans[len(spans)-1]
+	assert.Equal(t, "Store.TransferTx", root.Name)
+
+	children := make(map[string]int)
+	for _, span := range spans[:len(spans)-1] {
+		assert.Equal(t, root.SpanContext.TraceID(), span.SpanContext.TraceID())
+		assert.Equal(t, root.SpanContext.SpanID(), span.Parent.SpanID())
+		children[span.Name]++
+	}
+	assert.Equal(t, 1, children["CreateTransfer"])
+	assert.Equal(t, 2, children["CreateEntry"])
+	assert.Equal(t, 2, children["AddAccountBalance"])
+}
+
+func TestQueryName(t *testing.T) {
+	assert.Equal(t, "GetAccount", queryName(getAccount))
+	assert.Equal(t, "query", queryName("SELECT 1"))
+	assert.Equal(t, "query", queryName("-- name: "))
+}
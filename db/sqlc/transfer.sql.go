@@ -11,20 +11,30 @@ const createTransfer = `-- name: CreateTransfer :one
 INSERT INTO transfers (
   from_account_id,
   to_account_id,
-  amount
+  amount,
+  to_amount,
+  exchange_rate
 ) VALUES (
-  $1, $2, $3
-) RETURNING id, from_account_id, to_account_id, amount, created_at
+  $1, $2, $3, $4, $5
+) RETURNING id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, reversed_amount, reversed_at, status
 `
 
 type CreateTransferParams struct {
-	FromAccountID int64 `db:"from_account_id"`
-	ToAccountID   int64 `db:"to_account_id"`
-	Amount        int64 `db:"amount"`
+	FromAccountID int64  `db:"from_account_id"`
+	ToAccountID   int64  `db:"to_account_id"`
+	Amount        int64  `db:"amount"`
+	ToAmount      int64  `db:"to_amount"`
+	ExchangeRate  string `db:"exchange_rate"`
 }
 
 func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
-	row := q.db.QueryRowContext(ctx, createTransfer, arg.FromAccountID, arg.ToAccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.ToAmount,
+		arg.ExchangeRate,
+	)
 	var i Transfer
 	err := row.Scan(
 		&i.ID,
@@ -32,6 +42,11 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.ReversedAmount,
+		&i.ReversedAt,
+		&i.Status,
 	)
 	return i, err
 }
@@ -46,7 +61,7 @@ func (q *Queries) DeleteTransfer(ctx context.Context, id int64) error {
 }
 
 const getTransfer = `-- name: GetTransfer :one
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, reversed_amount, reversed_at, status FROM transfers
 WHERE id = $1 LIMIT 1
 `
 
@@ -59,12 +74,41 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.ReversedAmount,
+		&i.ReversedAt,
+		&i.Status,
+	)
+	return i, err
+}
+
+const getTransferForUpdate = `-- name: GetTransferForUpdate :one
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, reversed_amount, reversed_at, status FROM transfers
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetTransferForUpdate(ctx context.Context, id int64) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, getTransferForUpdate, id)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.ReversedAmount,
+		&i.ReversedAt,
+		&i.Status,
 	)
 	return i, err
 }
 
 const listTransfers = `-- name: ListTransfers :many
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, reversed_amount, reversed_at, status FROM transfers
 ORDER BY id
 LIMIT $1
 OFFSET $2
@@ -90,6 +134,11 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.ToAmount,
+			&i.ExchangeRate,
+			&i.ReversedAmount,
+			&i.ReversedAt,
+			&i.Status,
 		); err != nil {
 			return nil, err
 		}
@@ -103,3 +152,170 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 	}
 	return items, nil
 }
+
+const listTransfersByAccount = `-- name: ListTransfersByAccount :many
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, reversed_amount, reversed_at, status FROM transfers
+WHERE from_account_id = $1 OR to_account_id = $2
+ORDER BY id
+LIMIT $3
+OFFSET $4
+`
+
+type ListTransfersByAccountParams struct {
+	FromAccountID int64 `db:"from_account_id"`
+	ToAccountID   int64 `db:"to_account_id"`
+	Limit         int32 `db:"limit"`
+	Offset        int32 `db:"offset"`
+}
+
+func (q *Queries) ListTransfersByAccount(ctx context.Context, arg ListTransfersByAccountParams) ([]Transfer, error) {
+	rows, err := q.db.QueryContext(ctx, listTransfersByAccount,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.ToAmount,
+			&i.ExchangeRate,
+			&i.ReversedAmount,
+			&i.ReversedAt,
+			&i.Status,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransfersByAccountAfter = `-- name: ListTransfersByAccountAfter :many
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, reversed_amount, reversed_at, status FROM transfers
+WHERE (from_account_id = $1 OR to_account_id = $2) AND id > $3
+ORDER BY id
+LIMIT $4
+`
+
+type ListTransfersByAccountAfterParams struct {
+	FromAccountID int64 `db:"from_account_id"`
+	ToAccountID   int64 `db:"to_account_id"`
+	Cursor        int64 `db:"cursor"`
+	Limit         int32 `db:"limit"`
+}
+
+func (q *Queries) ListTransfersByAccountAfter(ctx context.Context, arg ListTransfersByAccountAfterParams) ([]Transfer, error) {
+	rows, err := q.db.QueryContext(ctx, listTransfersByAccountAfter,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Cursor,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.ToAmount,
+			&i.ExchangeRate,
+			&i.ReversedAmount,
+			&i.ReversedAt,
+			&i.Status,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateTransferReversal = `-- name: UpdateTransferReversal :one
+UPDATE transfers
+SET reversed_amount = $2, reversed_at = now()
+WHERE id = $1
+RETURNING id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, reversed_amount, reversed_at, status
+`
+
+type UpdateTransferReversalParams struct {
+	ID             int64 `db:"id"`
+	ReversedAmount int64 `db:"reversed_amount"`
+}
+
+func (q *Queries) UpdateTransferReversal(ctx context.Context, arg UpdateTransferReversalParams) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, updateTransferReversal, arg.ID, arg.ReversedAmount)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.ReversedAmount,
+		&i.ReversedAt,
+		&i.Status,
+	)
+	return i, err
+}
+
+const updateTransferStatus = `-- name: UpdateTransferStatus :one
+UPDATE transfers
+SET status = $2
+WHERE id = $1
+RETURNING id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, reversed_amount, reversed_at, status
+`
+
+type UpdateTransferStatusParams struct {
+	ID     int64          `db:"id"`
+	Status TransferStatus `db:"status"`
+}
+
+func (q *Queries) UpdateTransferStatus(ctx context.Context, arg UpdateTransferStatusParams) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, updateTransferStatus, arg.ID, arg.Status)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.ReversedAmount,
+		&i.ReversedAt,
+		&i.Status,
+	)
+	return i, err
+}
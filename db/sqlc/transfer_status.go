@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidTransferTransition is returned when a transfer can't move from its current status to the next one
+var ErrInvalidTransferTransition = errors.New("invalid transfer status transition")
+
+// transferTransitions lists the statuses a transfer can move to from each status.
+// A pending transfer is settled to completed or failed, only a completed transfer can be reversed
+var transferTransitions = map[TransferStatus][]TransferStatus{
+	TransferStatusPending:   {TransferStatusCompleted, TransferStatusFailed},
+	TransferStatusCompleted: {TransferStatusReversed},
+}
+
+// canTransitionTo reports whether a transfer of the status can move to the next status
+func (s TransferStatus) canTransitionTo(next TransferStatus) bool {
+	for _, status := range transferTransitions[s] {
+		if status == next {
+			return true
+		}
+	}
+	return false
+}
+
+// transitionTransfer moves the transfer to the next status and records the change along with its time
+func transitionTransfer(ctx context.Context, q *Queries, transfer Transfer, next TransferStatus) (Transfer, error) {
+	if !transfer.Status.canTransitionTo(next) {
+		return Transfer{}, fmt.Errorf("%w: %s to %s", ErrInvalidTransferTransition, transfer.Status, next)
+	}
+
+	transfer, err := q.UpdateTransferStatus(ctx, UpdateTransferStatusParams{
+		ID:     transfer.ID,
+		Status: next,
+	})
+	if err != nil {
+		return Transfer{}, err
+	}
+
+	_, err = q.CreateTransferStatusChange(ctx, CreateTransferStatusChangeParams{
+		TransferID: transfer.ID,
+		Status:     next,
+	})
+	return transfer, err
+}
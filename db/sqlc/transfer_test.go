@@ -11,10 +11,13 @@ import (
 
 // createRandomTransfer creates a random amount of transfer by given 2 accounts
 func createRandomTransfer(t *testing.T, from Account, to Account) Transfer {
+	amount := randomMoney()
 	arg := CreateTransferParams{
 		FromAccountID: from.ID,
 		ToAccountID:   to.ID,
-		Amount:        randomMoney(),
+		Amount:        amount,
+		ToAmount:      amount,
+		ExchangeRate:  "1",
 	}
 	transfer, err := testQueries.CreateTransfer(context.Background(), arg)
 	assert.NoError(t, err)
@@ -26,6 +29,9 @@ func createRandomTransfer(t *testing.T, from Account, to Account) Transfer {
 	assert.Equal(t, arg.FromAccountID, transfer.FromAccountID)
 	assert.Equal(t, arg.ToAccountID, transfer.ToAccountID)
 	assert.Equal(t, arg.Amount, transfer.Amount)
+	assert.Equal(t, arg.ToAmount, transfer.ToAmount)
+	assert.Equal(t, arg.ExchangeRate, transfer.ExchangeRate)
+	assert.Equal(t, TransferStatusPending, transfer.Status)
 
 	return transfer
 }
@@ -73,6 +79,67 @@ func TestListTransfers(t *testing.T) {
 	assert.GreaterOrEqual(t, len(transfers)-count, total)
 }
 
+// TestListTransfersByAccount makes sure list transfers from or to given account
+func TestListTransfersByAccount(t *testing.T) {
+	account := createRandomAccount(t)
+	other := createRandomAccount(t)
+
+	for i := 0; i < 3; i++ {
+		createRandomTransfer(t, account, other)
+		createRandomTransfer(t, other, account)
+	}
+
+	testCases := []struct {
+		name     string
+		fromID   int64
+		toID     int64
+		expected int
+	}{
+		{"all", account.ID, account.ID, 6},
+		{"out", account.ID, 0, 3},
+		{"in", 0, account.ID, 3},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			transfers, err := testQueries.ListTransfersByAccount(context.Background(), ListTransfersByAccountParams{
+				FromAccountID: tc.fromID,
+				ToAccountID:   tc.toID,
+				Limit:         math.MaxInt32,
+				Offset:        0,
+			})
+			assert.NoError(t, err)
+			assert.Len(t, transfers, tc.expected)
+
+			for _, transfer := range transfers {
+				assert.True(t, transfer.FromAccountID == tc.fromID || transfer.ToAccountID == tc.toID)
+			}
+		})
+	}
+}
+
+func TestListTransfersByAccountAfter(t *testing.T) {
+	account := createRandomAccount(t)
+	other := createRandomAccount(t)
+
+	var transfers []Transfer
+	for i := 0; i < 3; i++ {
+		transfers = append(transfers, createRandomTransfer(t, account, other))
+		transfers = append(transfers, createRandomTransfer(t, other, account))
+	}
+
+	page, err := testQueries.ListTransfersByAccountAfter(context.Background(), ListTransfersByAccountAfterParams{
+		FromAccountID: account.ID,
+		ToAccountID:   account.ID,
+		Cursor:        transfers[1].ID,
+		Limit:         3,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, page, 3)
+	for i, transfer := range page {
+		assert.Equal(t, transfers[i+2].ID, transfer.ID)
+	}
+}
+
 // TestDeleteTransfer makes sure delete transfer record by given ID
 func TestDeleteTransfer(t *testing.T) {
 	transfer1 := createRandomTransfer(t, createRandomAccount(t), createRandomAccount(t))
@@ -0,0 +1,42 @@
+package db
+
+import "context"
+
+// DepositTxParams holds the input parameter of deposit transaction
+type DepositTxParams struct {
+	AccountID int64 `json:"account_id"`
+	Amount    int64 `json:"amount"`
+}
+
+// DepositTxResult is the result of deposit transaction
+type DepositTxResult struct {
+	Account Account `json:"account"`
+	Entry   Entry   `json:"entry"`
+}
+
+// DepositTx adds money to the account
+// It creates an account entry and update account's balance
+func (s *SQLStore) DepositTx(ctx context.Context, arg DepositTxParams) (DepositTxResult, error) {
+	var result DepositTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		var err error
+		result.Account, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+			ID:     arg.AccountID,
+			Amount: arg.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Entry, err = q.CreateEntry(ctx, CreateEntryParams{
+			AccountID:    arg.AccountID,
+			Amount:       arg.Amount,
+			Type:         EntryTypeDeposit,
+			BalanceAfter: result.Account.Balance,
+		})
+		return err
+	})
+
+	return result, err
+}
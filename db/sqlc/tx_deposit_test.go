@@ -0,0 +1,33 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDepositTx makes sure deposit creates an entry and adds the account balance
+func TestDepositTx(t *testing.T) {
+	account := createRandomAccount(t)
+	amount := randomInt(1, 1000)
+
+	result, err := testStore.DepositTx(context.Background(), DepositTxParams{
+		AccountID: account.ID,
+		Amount:    amount,
+	})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, result)
+
+	assert.NotZero(t, result.Entry.ID)
+	assert.Equal(t, account.ID, result.Entry.AccountID)
+	assert.Equal(t, amount, result.Entry.Amount)
+	assert.Equal(t, EntryTypeDeposit, result.Entry.Type)
+	assert.False(t, result.Entry.TransferID.Valid)
+	assert.Equal(t, account.Balance+amount, result.Entry.BalanceAfter)
+	_, err = testStore.GetEntry(context.Background(), result.Entry.ID)
+	assert.NoError(t, err)
+
+	assert.Equal(t, account.ID, result.Account.ID)
+	assert.Equal(t, account.Balance+amount, result.Account.Balance)
+}
@@ -0,0 +1,125 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ErrInvalidExchangeRate is returned when the exchange rate is not a positive number
+var ErrInvalidExchangeRate = errors.New("invalid exchange rate")
+
+// ErrConvertedAmountTooSmall is returned when the amount converts to less than one minor unit of the to account's currency
+var ErrConvertedAmountTooSmall = errors.New("converted amount must be at least one minor unit")
+
+// FXTransferTxParams holds the input parameter of cross-currency transfer transaction.
+// Amount is in the minor unit of the from account's currency, and ExchangeRate is
+// the number of to account's currency units bought by one from account's currency unit
+type FXTransferTxParams struct {
+	TransferTxParams
+	ExchangeRate string `json:"exchange_rate"`
+}
+
+// FXTransferTx performs a money transfer between accounts of different currencies.
+// It debits the amount in the from account's currency and credits the converted
+// amount in the to account's currency, both amounts and the rate are recorded on the transfer
+func (s *SQLStore) FXTransferTx(ctx context.Context, arg FXTransferTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		var err error
+		result, err = fxTransfer(ctx, q, arg)
+		return err
+	})
+
+	logTransfer(ctx, arg.TransferTxParams, result, err)
+	return result, err
+}
+
+// fxTransfer runs the steps of FXTransferTx with the queries bound to an existing transaction
+func fxTransfer(ctx context.Context, q *Queries, arg FXTransferTxParams) (TransferTxResult, error) {
+	rate, ok := new(big.Rat).SetString(arg.ExchangeRate)
+	if !ok || rate.Sign() <= 0 {
+		return TransferTxResult{}, fmt.Errorf("%w: %q", ErrInvalidExchangeRate, arg.ExchangeRate)
+	}
+
+	fromExponent, err := accountCurrencyExponent(ctx, q, arg.FromAccountID)
+	if err != nil {
+		return TransferTxResult{}, err
+	}
+	toExponent, err := accountCurrencyExponent(ctx, q, arg.ToAccountID)
+	if err != nil {
+		return TransferTxResult{}, err
+	}
+
+	toAmount, err := convertAmount(arg.Amount, rate, fromExponent, toExponent)
+	if err != nil {
+		return TransferTxResult{}, err
+	}
+
+	return executeTransfer(ctx, q, CreateTransferParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+		ToAmount:      toAmount,
+		ExchangeRate:  arg.ExchangeRate,
+	})
+}
+
+// accountCurrencyExponent returns the minor unit exponent of the account's currency
+func accountCurrencyExponent(ctx context.Context, q *Queries, accountID int64) (int32, error) {
+	account, err := q.GetAccount(ctx, accountID)
+	if err != nil {
+		return 0, err
+	}
+	currency, err := q.GetCurrency(ctx, account.Currency)
+	if err != nil {
+		return 0, err
+	}
+	return currency.Exponent, nil
+}
+
+// convertAmount converts an amount in minor units with the exchange rate of the major units.
+// The result is rounded half to even, so the same input always yields the same amount
+// and rounding errors don't accumulate in one direction.
+// An amount rounded down to nothing is rejected so the sender is never debited for no credit
+func convertAmount(amount int64, rate *big.Rat, fromExponent, toExponent int32) (int64, error) {
+	converted := new(big.Rat).SetInt64(amount)
+	converted.Mul(converted, rate)
+	converted.Mul(converted, new(big.Rat).SetInt(pow10(toExponent)))
+	converted.Quo(converted, new(big.Rat).SetInt(pow10(fromExponent)))
+
+	toAmount, err := roundHalfEven(converted)
+	if err != nil {
+		return 0, err
+	}
+	if toAmount <= 0 {
+		return 0, fmt.Errorf("%w: %d converts to %s", ErrConvertedAmountTooSmall, amount, converted.FloatString(int(toExponent)+1))
+	}
+	return toAmount, nil
+}
+
+// roundHalfEven rounds the number to the nearest integer, and to the even one on a tie
+func roundHalfEven(r *big.Rat) (int64, error) {
+	quo, rem := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	// compare twice of the remainder with the denominator to find the nearest integer
+	switch rem.Abs(rem).Lsh(rem, 1).Cmp(r.Denom()) {
+	case 1:
+		quo.Add(quo, big.NewInt(int64(r.Sign())))
+	case 0:
+		if quo.Bit(0) == 1 {
+			quo.Add(quo, big.NewInt(int64(r.Sign())))
+		}
+	}
+
+	if !quo.IsInt64() {
+		return 0, fmt.Errorf("%s overflows int64", r.RatString())
+	}
+	return quo.Int64(), nil
+}
+
+// pow10 returns 10 to the power of n
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,140 @@
+package db
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// createAccountInCurrency creates an account of a new user in the given currency
+func createAccountInCurrency(t *testing.T, currency string) Account {
+	user := createRandomUser(t)
+	account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Username: user.Username,
+		Balance:  0,
+		Currency: currency,
+	})
+	assert.NoError(t, err)
+	return account
+}
+
+// TestFXTransferTx makes sure the converted amount is credited in the to account's currency
+func TestFXTransferTx(t *testing.T) {
+	fromAccount := fundAccount(t, createAccountInCurrency(t, "USD"), 10000)
+	toAccount := createAccountInCurrency(t, "JPY")
+
+	result, err := testStore.FXTransferTx(context.Background(), FXTransferTxParams{
+		TransferTxParams: TransferTxParams{
+			FromAccountID: fromAccount.ID,
+			ToAccountID:   toAccount.ID,
+			Amount:        1050,
+		},
+		ExchangeRate: "150.25",
+	})
+	assert.NoError(t, err)
+
+	// $10.50 * 150.25 = ¥1577.625, rounded to ¥1578
+	assert.Equal(t, int64(1050), result.Transfer.Amount)
+	assert.Equal(t, int64(1578), result.Transfer.ToAmount)
+	assert.Equal(t, "150.25", result.Transfer.ExchangeRate)
+
+	assert.Equal(t, int64(-1050), result.FromEntry.Amount)
+	assert.Equal(t, int64(1578), result.ToEntry.Amount)
+	assert.Equal(t, fromAccount.Balance-1050, result.FromAccount.Balance)
+	assert.Equal(t, toAccount.Balance+1578, result.ToAccount.Balance)
+
+	transfer, err := testStore.GetTransfer(context.Background(), result.Transfer.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, result.Transfer, transfer)
+}
+
+func TestFXTransferTxInsufficientFunds(t *testing.T) {
+	fromAccount := createAccountInCurrency(t, "USD")
+	toAccount := createAccountInCurrency(t, "TWD")
+
+	_, err := testStore.FXTransferTx(context.Background(), FXTransferTxParams{
+		TransferTxParams: TransferTxParams{
+			FromAccountID: fromAccount.ID,
+			ToAccountID:   toAccount.ID,
+			Amount:        100,
+		},
+		ExchangeRate: "30",
+	})
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+
+	toAccount, err = testStore.GetAccount(context.Background(), toAccount.ID)
+	assert.NoError(t, err)
+	assert.Zero(t, toAccount.Balance)
+}
+
+func TestFXTransferTxInvalidRate(t *testing.T) {
+	fromAccount := fundAccount(t, createAccountInCurrency(t, "USD"), 100)
+	toAccount := createAccountInCurrency(t, "TWD")
+
+	for _, rate := range []string{"", "abc", "0", "-1"} {
+		_, err := testStore.FXTransferTx(context.Background(), FXTransferTxParams{
+			TransferTxParams: TransferTxParams{
+				FromAccountID: fromAccount.ID,
+				ToAccountID:   toAccount.ID,
+				Amount:        100,
+			},
+			ExchangeRate: rate,
+		})
+		assert.ErrorIs(t, err, ErrInvalidExchangeRate)
+	}
+}
+
+func TestConvertAmount(t *testing.T) {
+	testCases := []struct {
+		name         string
+		amount       int64
+		rate         string
+		fromExponent int32
+		toExponent   int32
+		expected     int64
+	}{
+		{"same exponent", 1000, "30.5", 2, 2, 30500},
+		{"to fewer decimals", 1050, "150.25", 2, 0, 1578},
+		{"to more decimals", 100, "0.0066", 0, 2, 66},
+		{"round half to even down", 25, "0.1", 2, 2, 2},
+		{"round half to even up", 35, "0.1", 2, 2, 4},
+		{"round below half", 24, "0.1", 2, 2, 2},
+		{"round above half", 26, "0.1", 2, 2, 3},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rate, ok := new(big.Rat).SetString(tc.rate)
+			assert.True(t, ok)
+
+			got, err := convertAmount(tc.amount, rate, tc.fromExponent, tc.toExponent)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+// TestConvertAmountTooSmall makes sure an amount isn't converted into nothing
+func TestConvertAmountTooSmall(t *testing.T) {
+	testCases := []struct {
+		name         string
+		amount       int64
+		rate         string
+		fromExponent int32
+		toExponent   int32
+	}{
+		{"rounded down to zero", 1, "0.4", 2, 2},
+		{"round half to even zero", 5, "0.1", 2, 2},
+		{"to fewer decimals", 1, "0.0066", 2, 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rate, ok := new(big.Rat).SetString(tc.rate)
+			assert.True(t, ok)
+
+			_, err := convertAmount(tc.amount, rate, tc.fromExponent, tc.toExponent)
+			assert.ErrorIs(t, err, ErrConvertedAmountTooSmall)
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrHoldNotActive is returned when capturing or releasing a hold which is already captured, released or expired
+var ErrHoldNotActive = errors.New("hold is not active")
+
+// ErrHoldExpired is returned when capturing a hold after it expires
+var ErrHoldExpired = errors.New("hold is expired")
+
+// ErrInvalidCaptureAmount is returned when the capture amount exceeds the hold amount
+var ErrInvalidCaptureAmount = errors.New("capture amount must be between 1 and the hold amount")
+
+// PlaceHoldTxParams holds the input parameter of place hold transaction
+type PlaceHoldTxParams struct {
+	AccountID   int64     `json:"account_id"`
+	ToAccountID int64     `json:"to_account_id"`
+	Amount      int64     `json:"amount"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// PlaceHoldTx reserves the amount of the account for a later transfer to the to account.
+// No money is moved, but the held amount is no longer available until the hold is
+// captured, released or expired.
+// It fails with ErrInsufficientFunds if the available balance can't cover the hold
+func (s *SQLStore) PlaceHoldTx(ctx context.Context, arg PlaceHoldTxParams) (Hold, error) {
+	var hold Hold
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		// lock the account so concurrent holds and transfers see each other
+		account, err := q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		hold, err = q.CreateHold(ctx, CreateHoldParams{
+			AccountID:   arg.AccountID,
+			ToAccountID: arg.ToAccountID,
+			Amount:      arg.Amount,
+			ExpiresAt:   arg.ExpiresAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		return checkSufficientFunds(ctx, q, account)
+	})
+
+	return hold, err
+}
+
+// CaptureHoldTxParams holds the input parameter of capture hold transaction.
+// The whole hold is captured if Amount is zero
+type CaptureHoldTxParams struct {
+	HoldID int64 `json:"hold_id"`
+	Amount int64 `json:"amount"`
+}
+
+// CaptureHoldTxResult is the result of capture hold transaction
+type CaptureHoldTxResult struct {
+	TransferTxResult
+	Hold Hold `json:"hold"`
+}
+
+// CaptureHoldTx turns an active hold into a transfer to the to account of the hold.
+// The hold is captured once, a partial capture releases the rest of the held amount
+func (s *SQLStore) CaptureHoldTx(ctx context.Context, arg CaptureHoldTxParams) (CaptureHoldTxResult, error) {
+	var result CaptureHoldTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		hold, err := lockActiveHold(ctx, q, arg.HoldID)
+		if err != nil {
+			return err
+		}
+
+		amount := arg.Amount
+		if amount == 0 {
+			amount = hold.Amount
+		}
+		if amount < 0 || amount > hold.Amount {
+			return ErrInvalidCaptureAmount
+		}
+
+		transfer, err := authorizeTransfer(ctx, q, CreateTransferParams{
+			FromAccountID: hold.AccountID,
+			ToAccountID:   hold.ToAccountID,
+			Amount:        amount,
+			ToAmount:      amount,
+			ExchangeRate:  "1",
+		})
+		if err != nil {
+			return err
+		}
+
+		// the captured hold no longer reserves the money moved by the transfer
+		result.Hold, err = q.CaptureHold(ctx, CaptureHoldParams{
+			ID:         hold.ID,
+			TransferID: sql.NullInt64{Int64: transfer.ID, Valid: true},
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			// the locked hold is only left out once it's expired by the db clock,
+			// the same clock which stops counting it in the held amount
+			return ErrHoldExpired
+		}
+		if err != nil {
+			return err
+		}
+
+		result.TransferTxResult, err = settleTransfer(ctx, q, transfer)
+		return err
+	})
+
+	return result, err
+}
+
+// ReleaseHoldTx releases an active hold, so the held amount is available again
+func (s *SQLStore) ReleaseHoldTx(ctx context.Context, holdID int64) (Hold, error) {
+	var hold Hold
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		_, err := lockActiveHold(ctx, q, holdID)
+		if err != nil {
+			return err
+		}
+
+		hold, err = q.UpdateHoldStatus(ctx, UpdateHoldStatusParams{
+			ID:     holdID,
+			Status: HoldStatusReleased,
+		})
+		return err
+	})
+
+	return hold, err
+}
+
+// lockActiveHold locks the hold until the transaction ends and makes sure it's still active
+func lockActiveHold(ctx context.Context, q *Queries, holdID int64) (Hold, error) {
+	hold, err := q.GetHoldForUpdate(ctx, holdID)
+	if err != nil {
+		return Hold{}, err
+	}
+	if hold.Status != HoldStatusActive {
+		return Hold{}, ErrHoldNotActive
+	}
+	return hold, nil
+}
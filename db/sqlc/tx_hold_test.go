@@ -0,0 +1,145 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// placeTestHold places a hold on the account which expires after the duration
+func placeTestHold(t *testing.T, from, to Account, amount int64, duration time.Duration) Hold {
+	hold, err := testStore.PlaceHoldTx(context.Background(), PlaceHoldTxParams{
+		AccountID:   from.ID,
+		ToAccountID: to.ID,
+		Amount:      amount,
+		ExpiresAt:   time.Now().Add(duration),
+	})
+	assert.NoError(t, err)
+	assert.NotZero(t, hold.ID)
+	assert.Equal(t, from.ID, hold.AccountID)
+	assert.Equal(t, to.ID, hold.ToAccountID)
+	assert.Equal(t, amount, hold.Amount)
+	assert.Equal(t, HoldStatusActive, hold.Status)
+	assert.False(t, hold.TransferID.Valid)
+	return hold
+}
+
+func TestPlaceHoldTx(t *testing.T) {
+	fromAccount := fundAccount(t, createAccountInCurrency(t, "USD"), 100)
+	toAccount := createAccountInCurrency(t, "USD")
+
+	placeTestHold(t, fromAccount, toAccount, 60, time.Minute)
+
+	held, err := testQueries.GetAccountHeldAmount(context.Background(), fromAccount.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(60), held)
+
+	// the held amount is not available to another hold
+	_, err = testStore.PlaceHoldTx(context.Background(), PlaceHoldTxParams{
+		AccountID:   fromAccount.ID,
+		ToAccountID: toAccount.ID,
+		Amount:      50,
+		ExpiresAt:   time.Now().Add(time.Minute),
+	})
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// nor to a transfer or a withdrawal
+	_, err = testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        50,
+	})
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+	_, err = testStore.WithdrawTx(context.Background(), WithdrawTxParams{
+		AccountID: fromAccount.ID,
+		Amount:    50,
+	})
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// the balance is not moved by the hold
+	account, err := testStore.GetAccount(context.Background(), fromAccount.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), account.Balance)
+}
+
+func TestCaptureHoldTx(t *testing.T) {
+	fromAccount := fundAccount(t, createAccountInCurrency(t, "USD"), 100)
+	toAccount := createAccountInCurrency(t, "USD")
+	hold := placeTestHold(t, fromAccount, toAccount, 60, time.Minute)
+
+	result, err := testStore.CaptureHoldTx(context.Background(), CaptureHoldTxParams{
+		HoldID: hold.ID,
+		Amount: 40,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, HoldStatusCaptured, result.Hold.Status)
+	assert.Equal(t, result.Transfer.ID, result.Hold.TransferID.Int64)
+	assert.Equal(t, int64(40), result.Transfer.Amount)
+	assert.Equal(t, TransferStatusCompleted, result.Transfer.Status)
+	assert.Equal(t, int64(60), result.FromAccount.Balance)
+	assert.Equal(t, int64(40), result.ToAccount.Balance)
+
+	// the rest of the hold is released
+	held, err := testQueries.GetAccountHeldAmount(context.Background(), fromAccount.ID)
+	assert.NoError(t, err)
+	assert.Zero(t, held)
+
+	_, err = testStore.CaptureHoldTx(context.Background(), CaptureHoldTxParams{HoldID: hold.ID})
+	assert.ErrorIs(t, err, ErrHoldNotActive)
+	_, err = testStore.ReleaseHoldTx(context.Background(), hold.ID)
+	assert.ErrorIs(t, err, ErrHoldNotActive)
+}
+
+func TestCaptureHoldTxInvalidAmount(t *testing.T) {
+	fromAccount := fundAccount(t, createAccountInCurrency(t, "USD"), 100)
+	hold := placeTestHold(t, fromAccount, createAccountInCurrency(t, "USD"), 60, time.Minute)
+
+	_, err := testStore.CaptureHoldTx(context.Background(), CaptureHoldTxParams{
+		HoldID: hold.ID,
+		Amount: 61,
+	})
+	assert.ErrorIs(t, err, ErrInvalidCaptureAmount)
+
+	got, err := testQueries.GetHold(context.Background(), hold.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, HoldStatusActive, got.Status)
+}
+
+func TestReleaseHoldTx(t *testing.T) {
+	fromAccount := fundAccount(t, createAccountInCurrency(t, "USD"), 100)
+	hold := placeTestHold(t, fromAccount, createAccountInCurrency(t, "USD"), 60, time.Minute)
+
+	released, err := testStore.ReleaseHoldTx(context.Background(), hold.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, HoldStatusReleased, released.Status)
+
+	held, err := testQueries.GetAccountHeldAmount(context.Background(), fromAccount.ID)
+	assert.NoError(t, err)
+	assert.Zero(t, held)
+
+	_, err = testStore.CaptureHoldTx(context.Background(), CaptureHoldTxParams{HoldID: hold.ID})
+	assert.ErrorIs(t, err, ErrHoldNotActive)
+}
+
+func TestExpireHolds(t *testing.T) {
+	fromAccount := fundAccount(t, createAccountInCurrency(t, "USD"), 100)
+	hold := placeTestHold(t, fromAccount, createAccountInCurrency(t, "USD"), 60, time.Second)
+	time.Sleep(time.Second)
+
+	// an expired hold no longer reserves money even before it's marked expired
+	held, err := testQueries.GetAccountHeldAmount(context.Background(), fromAccount.ID)
+	assert.NoError(t, err)
+	assert.Zero(t, held)
+	_, err = testStore.CaptureHoldTx(context.Background(), CaptureHoldTxParams{HoldID: hold.ID})
+	assert.ErrorIs(t, err, ErrHoldExpired)
+
+	n, err := testQueries.ExpireHolds(context.Background())
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, n, int64(1))
+
+	got, err := testQueries.GetHold(context.Background(), hold.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, HoldStatusExpired, got.Status)
+}
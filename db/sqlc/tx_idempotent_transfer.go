@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+)
+
+// ErrIdempotencyKeyReused is returned when an idempotency key is reused
+// with a request different from the one it was first used with
+var ErrIdempotencyKeyReused = errors.New("idempotency key is already used by a different request")
+
+// IdempotentTransferTxParams holds the input parameter of idempotent transfer transaction
+type IdempotentTransferTxParams struct {
+	TransferTxParams
+	Username       string `json:"username"`
+	IdempotencyKey string `json:"idempotency_key"`
+	RequestHash    string `json:"request_hash"`
+	// ExchangeRate is set for a cross-currency transfer, see FXTransferTx
+	ExchangeRate string `json:"exchange_rate,omitempty"`
+}
+
+// IdempotentTransferTxResult is the result of idempotent transfer transaction
+type IdempotentTransferTxResult struct {
+	TransferTxResult
+	// Replayed reports whether the result is the stored response of a previous request
+	Replayed bool `json:"-"`
+}
+
+// IdempotentTransferTx performs TransferTx at most once for each idempotency key of the user.
+// The transfer result is stored along with the key in the same transaction, so a repeated
+// request returns the stored result instead of creating a new transfer
+func (s *SQLStore) IdempotentTransferTx(ctx context.Context, arg IdempotentTransferTxParams) (IdempotentTransferTxResult, error) {
+	var result IdempotentTransferTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		// serialize concurrent requests with the same key until the transaction ends
+		err := q.LockIdempotencyKey(ctx, LockIdempotencyKeyParams{
+			Username:       arg.Username,
+			IdempotencyKey: arg.IdempotencyKey,
+		})
+		if err != nil {
+			return err
+		}
+
+		key, err := q.GetIdempotencyKey(ctx, GetIdempotencyKeyParams{
+			Username:       arg.Username,
+			IdempotencyKey: arg.IdempotencyKey,
+		})
+		if err == nil {
+			if key.RequestHash != arg.RequestHash {
+				return ErrIdempotencyKeyReused
+			}
+			result.Replayed = true
+			return json.Unmarshal(key.Response, &result.TransferTxResult)
+		}
+		if err != sql.ErrNoRows {
+			return err
+		}
+
+		if arg.ExchangeRate != "" {
+			result.TransferTxResult, err = fxTransfer(ctx, q, FXTransferTxParams{
+				TransferTxParams: arg.TransferTxParams,
+				ExchangeRate:     arg.ExchangeRate,
+			})
+		} else {
+			result.TransferTxResult, err = transfer(ctx, q, arg.TransferTxParams)
+		}
+		if err != nil {
+			return err
+		}
+
+		response, err := json.Marshal(result.TransferTxResult)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.CreateIdempotencyKey(ctx, CreateIdempotencyKeyParams{
+			Username:       arg.Username,
+			IdempotencyKey: arg.IdempotencyKey,
+			RequestHash:    arg.RequestHash,
+			Response:       response,
+		})
+		return err
+	})
+
+	// a replayed result was logged by the request which performed the transfer
+	if !result.Replayed {
+		logTransfer(ctx, arg.TransferTxParams, result.TransferTxResult, err)
+	}
+	return result, err
+}
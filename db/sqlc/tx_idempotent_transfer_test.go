@@ -0,0 +1,144 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/peienxie/go-bank/logging"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestIdempotentTransferTx makes sure a repeated request replays the stored result
+func TestIdempotentTransferTx(t *testing.T) {
+	amount := int64(10)
+	fromAccount := fundAccount(t, createRandomAccount(t), amount)
+	toAccount := createRandomAccount(t)
+
+	arg := IdempotentTransferTxParams{
+		TransferTxParams: TransferTxParams{
+			FromAccountID: fromAccount.ID,
+			ToAccountID:   toAccount.ID,
+			Amount:        amount,
+		},
+		Username:       fromAccount.Username,
+		IdempotencyKey: randomString(16),
+		RequestHash:    randomString(64),
+	}
+
+	result1, err := testStore.IdempotentTransferTx(context.Background(), arg)
+	assert.NoError(t, err)
+	assert.False(t, result1.Replayed)
+	assert.NotZero(t, result1.Transfer.ID)
+
+	result2, err := testStore.IdempotentTransferTx(context.Background(), arg)
+	assert.NoError(t, err)
+	assert.True(t, result2.Replayed)
+	assert.Equal(t, result1.Transfer.ID, result2.Transfer.ID)
+	assert.Equal(t, result1.FromEntry.ID, result2.FromEntry.ID)
+	assert.Equal(t, result1.ToEntry.ID, result2.ToEntry.ID)
+	assert.Equal(t, result1.FromAccount.Balance, result2.FromAccount.Balance)
+	assert.True(t, result1.Transfer.CreatedAt.Equal(result2.Transfer.CreatedAt))
+
+	// the money is only transferred once
+	updatedFromAccount, err := testStore.GetAccount(context.Background(), fromAccount.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, fromAccount.Balance-amount, updatedFromAccount.Balance)
+}
+
+// TestIdempotentTransferTxLogs makes sure the transfer is logged once, a replayed result isn't logged again
+func TestIdempotentTransferTxLogs(t *testing.T) {
+	amount := int64(10)
+	fromAccount := fundAccount(t, createRandomAccount(t), amount)
+	toAccount := createRandomAccount(t)
+
+	core, logs := observer.New(zap.InfoLevel)
+	ctx := logging.NewContext(context.Background(), zap.New(core))
+
+	arg := IdempotentTransferTxParams{
+		TransferTxParams: TransferTxParams{
+			FromAccountID: fromAccount.ID,
+			ToAccountID:   toAccount.ID,
+			Amount:        amount,
+		},
+		Username:       fromAccount.Username,
+		IdempotencyKey: randomString(16),
+		RequestHash:    randomString(64),
+	}
+	for i := 0; i < 2; i++ {
+		_, err := testStore.IdempotentTransferTx(ctx, arg)
+		assert.NoError(t, err)
+	}
+
+	entries := logs.FilterMessage("transfer completed").All()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, fromAccount.ID, entries[0].ContextMap()["from_account_id"])
+}
+
+// TestIdempotentTransferTxKeyReused makes sure a key can't be reused by a different request
+func TestIdempotentTransferTxKeyReused(t *testing.T) {
+	amount := int64(10)
+	fromAccount := fundAccount(t, createRandomAccount(t), amount*2)
+	toAccount := createRandomAccount(t)
+
+	arg := IdempotentTransferTxParams{
+		TransferTxParams: TransferTxParams{
+			FromAccountID: fromAccount.ID,
+			ToAccountID:   toAccount.ID,
+			Amount:        amount,
+		},
+		Username:       fromAccount.Username,
+		IdempotencyKey: randomString(16),
+		RequestHash:    randomString(64),
+	}
+
+	_, err := testStore.IdempotentTransferTx(context.Background(), arg)
+	assert.NoError(t, err)
+
+	arg.RequestHash = randomString(64)
+	_, err = testStore.IdempotentTransferTx(context.Background(), arg)
+	assert.ErrorIs(t, err, ErrIdempotencyKeyReused)
+}
+
+// TestIdempotentTransferTxConcurrent makes sure concurrent requests with the same key transfer once
+func TestIdempotentTransferTxConcurrent(t *testing.T) {
+	n := 5
+	amount := int64(10)
+	fromAccount := fundAccount(t, createRandomAccount(t), amount*int64(n))
+	toAccount := createRandomAccount(t)
+
+	arg := IdempotentTransferTxParams{
+		TransferTxParams: TransferTxParams{
+			FromAccountID: fromAccount.ID,
+			ToAccountID:   toAccount.ID,
+			Amount:        amount,
+		},
+		Username:       fromAccount.Username,
+		IdempotencyKey: randomString(16),
+		RequestHash:    randomString(64),
+	}
+
+	errChan := make(chan error)
+	resultChan := make(chan IdempotentTransferTxResult)
+	for i := 0; i < n; i++ {
+		go func() {
+			result, err := testStore.IdempotentTransferTx(context.Background(), arg)
+			errChan <- err
+			resultChan <- result
+		}()
+	}
+
+	transferIDs := make(map[int64]bool)
+	for i := 0; i < n; i++ {
+		err := <-errChan
+		assert.NoError(t, err)
+		result := <-resultChan
+		transferIDs[result.Transfer.ID] = true
+	}
+	assert.Len(t, transferIDs, 1)
+
+	updatedFromAccount, err := testStore.GetAccount(context.Background(), fromAccount.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, fromAccount.Balance-amount, updatedFromAccount.Balance)
+}
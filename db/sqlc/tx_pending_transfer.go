@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/peienxie/go-bank/logging"
+	"go.uber.org/zap"
+)
+
+// AuthorizeTransferTx records a pending transfer without moving any money yet.
+// The transfer is later settled by SettleTransferTx or failed by FailTransferTx
+func (s *SQLStore) AuthorizeTransferTx(ctx context.Context, arg TransferTxParams) (Transfer, error) {
+	var transfer Transfer
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		var err error
+		transfer, err = authorizeTransfer(ctx, q, CreateTransferParams{
+			FromAccountID: arg.FromAccountID,
+			ToAccountID:   arg.ToAccountID,
+			Amount:        arg.Amount,
+			ToAmount:      arg.Amount,
+			ExchangeRate:  "1",
+		})
+		return err
+	})
+
+	return transfer, err
+}
+
+// SettleTransferTx completes a pending transfer by creating its entries and updating account's balance.
+// If the from account has insufficient funds the transfer is marked failed and ErrInsufficientFunds is returned
+func (s *SQLStore) SettleTransferTx(ctx context.Context, transferID int64) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		// lock the transfer so it's settled only once
+		transfer, err := q.GetTransferForUpdate(ctx, transferID)
+		if err != nil {
+			return err
+		}
+		if !transfer.Status.canTransitionTo(TransferStatusCompleted) {
+			return fmt.Errorf("%w: %s to %s", ErrInvalidTransferTransition, transfer.Status, TransferStatusCompleted)
+		}
+
+		result, err = settleTransfer(ctx, q, transfer)
+		return err
+	})
+
+	logger := logging.FromContext(ctx).With(zap.Int64("transfer_id", transferID))
+	if errors.Is(err, ErrInsufficientFunds) {
+		// the settlement is rolled back, record the failure in its own transaction
+		// the caller still gets ErrInsufficientFunds when the transfer stays pending
+		if _, failErr := s.FailTransferTx(ctx, transferID); failErr != nil {
+			logger.Error("cannot fail transfer", zap.Error(err), zap.NamedError("fail_error", failErr))
+		} else {
+			logger.Warn("transfer failed", zap.Error(err))
+		}
+	} else if err != nil {
+		logger.Warn("cannot settle transfer", zap.Error(err))
+	} else {
+		logger.Info("transfer settled")
+	}
+
+	return result, err
+}
+
+// FailTransferTx marks a pending transfer failed, no money is moved by a failed transfer
+func (s *SQLStore) FailTransferTx(ctx context.Context, transferID int64) (Transfer, error) {
+	var transfer Transfer
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		var err error
+		transfer, err = q.GetTransferForUpdate(ctx, transferID)
+		if err != nil {
+			return err
+		}
+
+		transfer, err = transitionTransfer(ctx, q, transfer, TransferStatusFailed)
+		return err
+	})
+
+	return transfer, err
+}
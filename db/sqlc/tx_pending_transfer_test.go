@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// statusHistory returns the statuses the transfer went through in order
+func statusHistory(t *testing.T, transferID int64) []TransferStatus {
+	changes, err := testQueries.ListTransferStatusChanges(context.Background(), transferID)
+	assert.NoError(t, err)
+
+	var statuses []TransferStatus
+	for _, change := range changes {
+		assert.NotZero(t, change.CreatedAt)
+		statuses = append(statuses, change.Status)
+	}
+	return statuses
+}
+
+func TestAuthorizeAndSettleTransferTx(t *testing.T) {
+	fromAccount := fundAccount(t, createAccountInCurrency(t, "USD"), 100)
+	toAccount := createAccountInCurrency(t, "USD")
+
+	transfer, err := testStore.AuthorizeTransferTx(context.Background(), TransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        30,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, TransferStatusPending, transfer.Status)
+
+	// no money is moved by the authorization
+	account, err := testStore.GetAccount(context.Background(), fromAccount.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), account.Balance)
+
+	result, err := testStore.SettleTransferTx(context.Background(), transfer.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, TransferStatusCompleted, result.Transfer.Status)
+	assert.Equal(t, int64(70), result.FromAccount.Balance)
+	assert.Equal(t, int64(30), result.ToAccount.Balance)
+	assert.Equal(t, transfer.ID, result.FromEntry.TransferID.Int64)
+	assert.Equal(t, transfer.ID, result.ToEntry.TransferID.Int64)
+
+	assert.Equal(t, []TransferStatus{TransferStatusPending, TransferStatusCompleted}, statusHistory(t, transfer.ID))
+
+	// a completed transfer can't be settled again
+	_, err = testStore.SettleTransferTx(context.Background(), transfer.ID)
+	assert.ErrorIs(t, err, ErrInvalidTransferTransition)
+	_, err = testStore.FailTransferTx(context.Background(), transfer.ID)
+	assert.ErrorIs(t, err, ErrInvalidTransferTransition)
+}
+
+func TestSettleTransferTxInsufficientFunds(t *testing.T) {
+	fromAccount := createAccountInCurrency(t, "USD")
+	toAccount := createAccountInCurrency(t, "USD")
+
+	transfer, err := testStore.AuthorizeTransferTx(context.Background(), TransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        10,
+	})
+	assert.NoError(t, err)
+
+	_, err = testStore.SettleTransferTx(context.Background(), transfer.ID)
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// the settlement is rolled back and the transfer is failed
+	got, err := testStore.GetTransfer(context.Background(), transfer.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, TransferStatusFailed, got.Status)
+	account, err := testStore.GetAccount(context.Background(), fromAccount.ID)
+	assert.NoError(t, err)
+	assert.Zero(t, account.Balance)
+
+	assert.Equal(t, []TransferStatus{TransferStatusPending, TransferStatusFailed}, statusHistory(t, transfer.ID))
+
+	// a failed transfer can't be settled
+	_, err = testStore.SettleTransferTx(context.Background(), transfer.ID)
+	assert.ErrorIs(t, err, ErrInvalidTransferTransition)
+}
+
+func TestTransferTxStatusHistory(t *testing.T) {
+	fromAccount := fundAccount(t, createAccountInCurrency(t, "USD"), 100)
+	toAccount := createAccountInCurrency(t, "USD")
+
+	result, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        10,
+	})
+	assert.NoError(t, err)
+
+	_, err = testStore.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: result.Transfer.ID,
+	})
+	assert.NoError(t, err)
+
+	got, err := testStore.GetTransfer(context.Background(), result.Transfer.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, TransferStatusReversed, got.Status)
+	assert.Equal(t, []TransferStatus{
+		TransferStatusPending,
+		TransferStatusCompleted,
+		TransferStatusReversed,
+	}, statusHistory(t, result.Transfer.ID))
+}
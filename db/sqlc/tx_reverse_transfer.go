@@ -0,0 +1,132 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ErrTransferAlreadyReversed is returned when reversing a transfer whose whole amount is already reversed
+var ErrTransferAlreadyReversed = errors.New("transfer is already reversed")
+
+// ErrInvalidReversalAmount is returned when the reversal amount exceeds the amount not reversed yet
+var ErrInvalidReversalAmount = errors.New("reversal amount must be between 1 and the amount not reversed yet")
+
+// ReverseTransferTxParams holds the input parameter of reverse transfer transaction.
+// Amount is in the from account's currency, the rest of the transfer is reversed if it's zero
+type ReverseTransferTxParams struct {
+	TransferID int64 `json:"transfer_id"`
+	Amount     int64 `json:"amount"`
+}
+
+// ReverseTransferTx moves the money of a transfer back to the from account.
+// It creates reversal entries linked to the transfer, updates account's balance
+// and adds the amount to the reversed amount of the completed transfer.
+// A transfer can be partially reversed several times, it's marked reversed once its whole amount is reversed.
+// A partial reversal of a cross-currency transfer takes back the same share of the converted amount.
+// The result holds the reversed transfer, and the accounts and entries of the reversal
+func (s *SQLStore) ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		// lock the transfer so concurrent reversals are serialized
+		transfer, err := q.GetTransferForUpdate(ctx, arg.TransferID)
+		if err != nil {
+			return err
+		}
+		if transfer.Status == TransferStatusReversed {
+			return ErrTransferAlreadyReversed
+		}
+
+		// only a completed transfer can be reversed
+		if !transfer.Status.canTransitionTo(TransferStatusReversed) {
+			return fmt.Errorf("%w: %s to %s", ErrInvalidTransferTransition, transfer.Status, TransferStatusReversed)
+		}
+
+		remaining := transfer.Amount - transfer.ReversedAmount
+		amount := arg.Amount
+		if amount == 0 {
+			amount = remaining
+		}
+		if amount < 0 || amount > remaining {
+			return ErrInvalidReversalAmount
+		}
+
+		// the converted amount is taken back by the share of the total reversed amount,
+		// so the rounding of several partial reversals adds up to the whole converted amount
+		reversed := transfer.ReversedAmount + amount
+		toAmount, err := reversedToAmount(transfer, reversed)
+		if err != nil {
+			return err
+		}
+		alreadyReversedToAmount, err := reversedToAmount(transfer, transfer.ReversedAmount)
+		if err != nil {
+			return err
+		}
+		toAmount -= alreadyReversedToAmount
+
+		// the transfer stays completed until its whole amount is reversed
+		if reversed == transfer.Amount {
+			transfer, err = transitionTransfer(ctx, q, transfer, TransferStatusReversed)
+			if err != nil {
+				return err
+			}
+		}
+
+		result.Transfer, err = q.UpdateTransferReversal(ctx, UpdateTransferReversalParams{
+			ID:             transfer.ID,
+			ReversedAmount: reversed,
+		})
+		if err != nil {
+			return err
+		}
+
+		// always perform transaction with lowest id first
+		if transfer.FromAccountID < transfer.ToAccountID {
+			result.FromAccount, result.ToAccount, err = transferMoney(ctx, q, transfer.FromAccountID, amount, transfer.ToAccountID, -toAmount)
+		} else {
+			result.ToAccount, result.FromAccount, err = transferMoney(ctx, q, transfer.ToAccountID, -toAmount, transfer.FromAccountID, amount)
+		}
+		if err != nil {
+			return err
+		}
+
+		transferID := sql.NullInt64{Int64: transfer.ID, Valid: true}
+		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+			AccountID:    transfer.FromAccountID,
+			Amount:       amount,
+			TransferID:   transferID,
+			Type:         EntryTypeReversal,
+			BalanceAfter: result.FromAccount.Balance,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+			AccountID:    transfer.ToAccountID,
+			Amount:       -toAmount,
+			TransferID:   transferID,
+			Type:         EntryTypeReversal,
+			BalanceAfter: result.ToAccount.Balance,
+		})
+		if err != nil {
+			return err
+		}
+
+		return checkSufficientFunds(ctx, q, result.ToAccount)
+	})
+
+	return result, err
+}
+
+// reversedToAmount returns the share of the converted amount for the reversed amount of the transfer
+func reversedToAmount(transfer Transfer, reversed int64) (int64, error) {
+	if reversed == transfer.Amount {
+		return transfer.ToAmount, nil
+	}
+	share := new(big.Int).Mul(big.NewInt(transfer.ToAmount), big.NewInt(reversed))
+	return roundHalfEven(new(big.Rat).SetFrac(share, big.NewInt(transfer.Amount)))
+}
@@ -0,0 +1,240 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestReverseTransferTx makes sure the whole transfer is moved back
+func TestReverseTransferTx(t *testing.T) {
+	fromAccount := fundAccount(t, createAccountInCurrency(t, "USD"), 100)
+	toAccount := createAccountInCurrency(t, "USD")
+
+	transfer, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        60,
+	})
+	assert.NoError(t, err)
+
+	result, err := testStore.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: transfer.Transfer.ID,
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, int64(60), result.Transfer.ReversedAmount)
+	assert.True(t, result.Transfer.ReversedAt.Valid)
+
+	assert.Equal(t, int64(60), result.FromEntry.Amount)
+	assert.Equal(t, EntryTypeReversal, result.FromEntry.Type)
+	assert.Equal(t, transfer.Transfer.ID, result.FromEntry.TransferID.Int64)
+	assert.Equal(t, int64(-60), result.ToEntry.Amount)
+	assert.Equal(t, EntryTypeReversal, result.ToEntry.Type)
+	assert.Equal(t, transfer.Transfer.ID, result.ToEntry.TransferID.Int64)
+
+	assert.Equal(t, fromAccount.Balance, result.FromAccount.Balance)
+	assert.Equal(t, toAccount.Balance, result.ToAccount.Balance)
+
+	// a transfer can only be reversed once
+	_, err = testStore.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: transfer.Transfer.ID,
+	})
+	assert.ErrorIs(t, err, ErrTransferAlreadyReversed)
+}
+
+// TestReverseTransferTxPartial makes sure the same share of the converted amount is taken back
+func TestReverseTransferTxPartial(t *testing.T) {
+	fromAccount := fundAccount(t, createAccountInCurrency(t, "USD"), 1000)
+	toAccount := createAccountInCurrency(t, "JPY")
+
+	transfer, err := testStore.FXTransferTx(context.Background(), FXTransferTxParams{
+		TransferTxParams: TransferTxParams{
+			FromAccountID: fromAccount.ID,
+			ToAccountID:   toAccount.ID,
+			Amount:        1000,
+		},
+		ExchangeRate: "1.5",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(15), transfer.Transfer.ToAmount)
+
+	// $3.33 of $10 is 33.3% of ¥15, rounded to ¥5
+	result, err := testStore.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: transfer.Transfer.ID,
+		Amount:     333,
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, int64(333), result.Transfer.ReversedAmount)
+	assert.Equal(t, int64(333), result.FromEntry.Amount)
+	assert.Equal(t, int64(-5), result.ToEntry.Amount)
+	assert.Equal(t, transfer.FromAccount.Balance+333, result.FromAccount.Balance)
+	assert.Equal(t, transfer.ToAccount.Balance-5, result.ToAccount.Balance)
+}
+
+// TestReverseTransferTxPartialUntilReversed makes sure partial reversals add up to the whole transfer
+func TestReverseTransferTxPartialUntilReversed(t *testing.T) {
+	fromAccount := fundAccount(t, createAccountInCurrency(t, "USD"), 1000)
+	toAccount := createAccountInCurrency(t, "JPY")
+
+	transfer, err := testStore.FXTransferTx(context.Background(), FXTransferTxParams{
+		TransferTxParams: TransferTxParams{
+			FromAccountID: fromAccount.ID,
+			ToAccountID:   toAccount.ID,
+			Amount:        1000,
+		},
+		ExchangeRate: "1.5",
+	})
+	assert.NoError(t, err)
+
+	// each reversal takes back its share of ¥15 by the total reversed so far
+	testCases := []struct {
+		amount         int64
+		toAmount       int64
+		reversedAmount int64
+		status         TransferStatus
+	}{
+		{333, 5, 333, TransferStatusCompleted},
+		{333, 5, 666, TransferStatusCompleted},
+		{0, 5, 1000, TransferStatusReversed},
+	}
+	for _, tc := range testCases {
+		result, err := testStore.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+			TransferID: transfer.Transfer.ID,
+			Amount:     tc.amount,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, tc.reversedAmount, result.Transfer.ReversedAmount)
+		assert.Equal(t, tc.status, result.Transfer.Status)
+		assert.Equal(t, -tc.toAmount, result.ToEntry.Amount)
+	}
+
+	updatedToAccount, err := testStore.GetAccount(context.Background(), toAccount.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, toAccount.Balance, updatedToAccount.Balance)
+
+	_, err = testStore.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: transfer.Transfer.ID,
+		Amount:     1,
+	})
+	assert.ErrorIs(t, err, ErrTransferAlreadyReversed)
+}
+
+func TestReverseTransferTxInvalidAmount(t *testing.T) {
+	fromAccount := fundAccount(t, createAccountInCurrency(t, "USD"), 100)
+	toAccount := createAccountInCurrency(t, "USD")
+
+	transfer, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        50,
+	})
+	assert.NoError(t, err)
+
+	for _, amount := range []int64{-1, 51} {
+		_, err = testStore.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+			TransferID: transfer.Transfer.ID,
+			Amount:     amount,
+		})
+		assert.ErrorIs(t, err, ErrInvalidReversalAmount)
+	}
+
+	// only the amount not reversed yet can be reversed
+	_, err = testStore.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: transfer.Transfer.ID,
+		Amount:     20,
+	})
+	assert.NoError(t, err)
+	_, err = testStore.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: transfer.Transfer.ID,
+		Amount:     31,
+	})
+	assert.ErrorIs(t, err, ErrInvalidReversalAmount)
+}
+
+// TestReverseTransferTxInsufficientFunds makes sure the reversal doesn't overdraw the to account
+func TestReverseTransferTxInsufficientFunds(t *testing.T) {
+	fromAccount := fundAccount(t, createAccountInCurrency(t, "USD"), 100)
+	toAccount := createAccountInCurrency(t, "USD")
+	otherAccount := createAccountInCurrency(t, "USD")
+
+	transfer, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        50,
+	})
+	assert.NoError(t, err)
+	_, err = testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: toAccount.ID,
+		ToAccountID:   otherAccount.ID,
+		Amount:        50,
+	})
+	assert.NoError(t, err)
+
+	_, err = testStore.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: transfer.Transfer.ID,
+	})
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// the failed reversal is rolled back
+	got, err := testStore.GetTransfer(context.Background(), transfer.Transfer.ID)
+	assert.NoError(t, err)
+	assert.False(t, got.ReversedAt.Valid)
+	assert.Equal(t, TransferStatusCompleted, got.Status)
+}
+
+// TestReverseTransferTxPending makes sure a transfer which isn't completed can't be reversed
+func TestReverseTransferTxPending(t *testing.T) {
+	transfer, err := testStore.AuthorizeTransferTx(context.Background(), TransferTxParams{
+		FromAccountID: createAccountInCurrency(t, "USD").ID,
+		ToAccountID:   createAccountInCurrency(t, "USD").ID,
+		Amount:        10,
+	})
+	assert.NoError(t, err)
+
+	_, err = testStore.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: transfer.ID,
+	})
+	assert.ErrorIs(t, err, ErrInvalidTransferTransition)
+}
+
+// TestReverseTransferTxConcurrent makes sure only one of the concurrent reversals succeeds
+func TestReverseTransferTxConcurrent(t *testing.T) {
+	n := 5
+	fromAccount := fundAccount(t, createAccountInCurrency(t, "USD"), 100)
+	toAccount := createAccountInCurrency(t, "USD")
+
+	transfer, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        100,
+	})
+	assert.NoError(t, err)
+
+	errChan := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := testStore.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+				TransferID: transfer.Transfer.ID,
+			})
+			errChan <- err
+		}()
+	}
+
+	var succeeded int
+	for i := 0; i < n; i++ {
+		err := <-errChan
+		if err == nil {
+			succeeded++
+			continue
+		}
+		assert.ErrorIs(t, err, ErrTransferAlreadyReversed)
+	}
+	assert.Equal(t, 1, succeeded)
+
+	updatedFromAccount, err := testStore.GetAccount(context.Background(), fromAccount.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, fromAccount.Balance, updatedFromAccount.Balance)
+}
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+// ScheduledTransferQuerier provides the queries bound to the transaction of a scheduled transfer run
+type ScheduledTransferQuerier interface {
+	Querier
+	// TransferTx performs the transfer in the transaction of the run, so the transfer
+	// is committed or rolled back along with the bookkeeping of the run
+	TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
+}
+
+// ScheduledTransferTx locks the earliest due scheduled transfer, skipping the ones already
+// locked by other workers, and calls fn with it and the queries bound to the transaction.
+// The lock is held until fn returns, so a due scheduled transfer is run by one worker at a time.
+// It returns sql.ErrNoRows if no scheduled transfer is due
+func (s *SQLStore) ScheduledTransferTx(ctx context.Context, fn func(q ScheduledTransferQuerier, scheduled ScheduledTransfer) error) error {
+	return s.execTx(ctx, func(q *Queries) error {
+		scheduled, err := q.GetDueScheduledTransferForUpdate(ctx)
+		if err != nil {
+			return err
+		}
+		return fn(scheduledTransferQueries{q}, scheduled)
+	})
+}
+
+// scheduledTransferQueries is the queries bound to the transaction of ScheduledTransferTx
+type scheduledTransferQueries struct {
+	*Queries
+}
+
+// TransferTx performs the transfer within a savepoint of the transaction, a failed
+// transfer is rolled back to the savepoint so the failed run can still be recorded
+func (q scheduledTransferQueries) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
+	if _, err := q.db.ExecContext(ctx, "SAVEPOINT scheduled_transfer"); err != nil {
+		return TransferTxResult{}, err
+	}
+
+	result, err := transfer(ctx, q.Queries, arg)
+	if err != nil {
+		if _, rollbackErr := q.db.ExecContext(ctx, "ROLLBACK TO SAVEPOINT scheduled_transfer"); rollbackErr != nil {
+			err = fmt.Errorf("transfer err: %w, rollback err: %v", err, rollbackErr)
+		}
+	} else {
+		_, err = q.db.ExecContext(ctx, "RELEASE SAVEPOINT scheduled_transfer")
+	}
+
+	logTransfer(ctx, arg, result, err)
+	return result, err
+}
@@ -0,0 +1,47 @@
+package db
+
+import "context"
+
+// WithdrawTxParams holds the input parameter of withdraw transaction
+type WithdrawTxParams struct {
+	AccountID int64 `json:"account_id"`
+	Amount    int64 `json:"amount"`
+}
+
+// WithdrawTxResult is the result of withdraw transaction
+type WithdrawTxResult struct {
+	Account Account `json:"account"`
+	Entry   Entry   `json:"entry"`
+}
+
+// WithdrawTx takes money out of the account
+// It creates an account entry and update account's balance
+// It fails with ErrInsufficientFunds if the account would be overdrawn
+func (s *SQLStore) WithdrawTx(ctx context.Context, arg WithdrawTxParams) (WithdrawTxResult, error) {
+	var result WithdrawTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		var err error
+		result.Account, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+			ID:     arg.AccountID,
+			Amount: -arg.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Entry, err = q.CreateEntry(ctx, CreateEntryParams{
+			AccountID:    arg.AccountID,
+			Amount:       -arg.Amount,
+			Type:         EntryTypeWithdrawal,
+			BalanceAfter: result.Account.Balance,
+		})
+		if err != nil {
+			return err
+		}
+
+		return checkSufficientFunds(ctx, q, result.Account)
+	})
+
+	return result, err
+}
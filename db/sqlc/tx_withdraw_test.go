@@ -0,0 +1,47 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWithdrawTx makes sure withdraw creates an entry and subtracts the account balance
+func TestWithdrawTx(t *testing.T) {
+	amount := randomInt(1, 1000)
+	account := fundAccount(t, createRandomAccount(t), amount)
+
+	result, err := testStore.WithdrawTx(context.Background(), WithdrawTxParams{
+		AccountID: account.ID,
+		Amount:    amount,
+	})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, result)
+
+	assert.NotZero(t, result.Entry.ID)
+	assert.Equal(t, account.ID, result.Entry.AccountID)
+	assert.Equal(t, -amount, result.Entry.Amount)
+	assert.Equal(t, EntryTypeWithdrawal, result.Entry.Type)
+	assert.False(t, result.Entry.TransferID.Valid)
+	_, err = testStore.GetEntry(context.Background(), result.Entry.ID)
+	assert.NoError(t, err)
+
+	assert.Equal(t, account.ID, result.Account.ID)
+	assert.Equal(t, account.Balance-amount, result.Account.Balance)
+}
+
+// TestWithdrawTxInsufficientFunds makes sure withdraw is rolled back when the balance is not enough
+func TestWithdrawTxInsufficientFunds(t *testing.T) {
+	account := createRandomAccount(t)
+
+	_, err := testStore.WithdrawTx(context.Background(), WithdrawTxParams{
+		AccountID: account.ID,
+		Amount:    account.Balance + 1,
+	})
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+
+	updatedAccount, err := testStore.GetAccount(context.Background(), account.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, account.Balance, updatedAccount.Balance)
+}
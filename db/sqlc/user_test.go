@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// createRandomUser generates a random user data for testing
+func createRandomUser(t *testing.T) User {
+	arg := CreateUserParams{
+		Username:       randomUsername(),
+		HashedPassword: randomString(60),
+		FullName:       randomUsername(),
+		Email:          randomEmail(),
+	}
+
+	user, err := testQueries.CreateUser(context.Background(), arg)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, user)
+
+	assert.Equal(t, arg.Username, user.Username)
+	assert.Equal(t, arg.HashedPassword, user.HashedPassword)
+	assert.Equal(t, arg.FullName, user.FullName)
+	assert.Equal(t, arg.Email, user.Email)
+
+	assert.True(t, user.PasswordChangedAt.IsZero())
+	assert.NotZero(t, user.CreatedAt)
+	assert.Equal(t, UserRoleCustomer, user.Role)
+
+	return user
+}
+
+// TestCreateUser makes sure create a new user in db
+func TestCreateUser(t *testing.T) {
+	user := createRandomUser(t)
+	assert.NotEmpty(t, user)
+}
+
+// TestGetUser makes sure get user by given username
+func TestGetUser(t *testing.T) {
+	user1 := createRandomUser(t)
+	user2, err := testQueries.GetUser(context.Background(), user1.Username)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, user2)
+
+	assert.Equal(t, user1.Username, user2.Username)
+	assert.Equal(t, user1.HashedPassword, user2.HashedPassword)
+	assert.Equal(t, user1.FullName, user2.FullName)
+	assert.Equal(t, user1.Email, user2.Email)
+	assert.Equal(t, user1.PasswordChangedAt, user2.PasswordChangedAt)
+	assert.Equal(t, user1.CreatedAt, user2.CreatedAt)
+	assert.Equal(t, user1.Role, user2.Role)
+}
+
+// TestGetUserNotFound makes sure get user returns error when username does not exist
+func TestGetUserNotFound(t *testing.T) {
+	user, err := testQueries.GetUser(context.Background(), randomUsername())
+	assert.Error(t, err)
+	assert.EqualError(t, err, sql.ErrNoRows.Error())
+	assert.Empty(t, user)
+}
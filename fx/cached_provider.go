@@ -0,0 +1,55 @@
+package fx
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CachedProvider caches the rates of another provider for a period of time
+type CachedProvider struct {
+	provider RateProvider
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	rate      Rate
+	expiredAt time.Time
+}
+
+// NewCachedProvider creates a rate provider caching the rates of the provider for ttl
+func NewCachedProvider(provider RateProvider, ttl time.Duration) *CachedProvider {
+	return &CachedProvider{
+		provider: provider,
+		ttl:      ttl,
+		now:      time.Now,
+		entries:  make(map[string]cacheEntry),
+	}
+}
+
+// Rate returns the cached rate of the currency pair, or looks it up
+// from the underlying provider if it's not cached or expired
+func (p *CachedProvider) Rate(ctx context.Context, from, to string) (Rate, error) {
+	key := from + "/" + to
+
+	p.mu.Lock()
+	entry, ok := p.entries[key]
+	p.mu.Unlock()
+	if ok && p.now().Before(entry.expiredAt) {
+		return entry.rate, nil
+	}
+
+	rate, err := p.provider.Rate(ctx, from, to)
+	if err != nil {
+		return Rate{}, err
+	}
+
+	p.mu.Lock()
+	p.entries[key] = cacheEntry{rate: rate, expiredAt: p.now().Add(p.ttl)}
+	p.mu.Unlock()
+	return rate, nil
+}
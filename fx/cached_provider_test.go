@@ -0,0 +1,61 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingProvider returns a fixed rate and counts the lookups
+type countingProvider struct {
+	rate  Rate
+	err   error
+	calls int
+}
+
+func (p *countingProvider) Rate(ctx context.Context, from, to string) (Rate, error) {
+	p.calls++
+	return p.rate, p.err
+}
+
+func TestCachedProvider(t *testing.T) {
+	now := testNow
+	stub := &countingProvider{rate: Rate{From: "USD", To: "TWD", Rate: "30.5"}}
+	provider := NewCachedProvider(stub, time.Minute)
+	provider.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		rate, err := provider.Rate(context.Background(), "USD", "TWD")
+		assert.NoError(t, err)
+		assert.Equal(t, stub.rate, rate)
+	}
+	assert.Equal(t, 1, stub.calls)
+
+	// currency pairs are cached separately
+	_, err := provider.Rate(context.Background(), "TWD", "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stub.calls)
+
+	// the rate is looked up again once the cache expired
+	now = now.Add(time.Minute)
+	stub.rate.Rate = "31"
+	rate, err := provider.Rate(context.Background(), "USD", "TWD")
+	assert.NoError(t, err)
+	assert.Equal(t, "31", rate.Rate)
+	assert.Equal(t, 3, stub.calls)
+}
+
+func TestCachedProviderError(t *testing.T) {
+	stub := &countingProvider{err: errors.New("unavailable")}
+	provider := NewCachedProvider(stub, time.Minute)
+
+	// errors are not cached
+	for i := 0; i < 2; i++ {
+		_, err := provider.Rate(context.Background(), "USD", "TWD")
+		assert.Error(t, err)
+	}
+	assert.Equal(t, 2, stub.calls)
+}
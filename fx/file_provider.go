@@ -0,0 +1,111 @@
+package fx
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileProvider provides the exchange rates listed in a JSON or CSV file.
+// The file is read on every lookup, so new rates can be published by replacing
+// the file, wrap it with CachedProvider to avoid reading the file on every request
+type FileProvider struct {
+	path string
+	now  func() time.Time
+}
+
+// NewFileProvider creates a rate provider reading the file of the path.
+// The format is decided by the file extension, either .json or .csv
+func NewFileProvider(path string) (*FileProvider, error) {
+	switch filepath.Ext(path) {
+	case ".json", ".csv":
+	default:
+		return nil, fmt.Errorf("unsupported exchange rate file %q", path)
+	}
+	return &FileProvider{path: path, now: time.Now}, nil
+}
+
+// Rate returns the latest effective rate of the currency pair in the file
+func (p *FileProvider) Rate(ctx context.Context, from, to string) (Rate, error) {
+	rates, err := p.readRates()
+	if err != nil {
+		return Rate{}, err
+	}
+
+	now := p.now()
+	var found bool
+	var latest Rate
+	for _, rate := range rates {
+		if rate.From != from || rate.To != to || rate.EffectiveAt.After(now) {
+			continue
+		}
+		if !found || rate.EffectiveAt.After(latest.EffectiveAt) {
+			latest = rate
+			found = true
+		}
+	}
+	if !found {
+		return Rate{}, ErrRateNotFound
+	}
+	return latest, nil
+}
+
+// readRates reads and validates all rates in the file
+func (p *FileProvider) readRates() ([]Rate, error) {
+	f, err := os.Open(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("open exchange rate file err: %w", err)
+	}
+	defer f.Close()
+
+	var rates []Rate
+	if filepath.Ext(p.path) == ".csv" {
+		rates, err = decodeCSV(f)
+	} else {
+		err = json.NewDecoder(f).Decode(&rates)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decode exchange rate file err: %w", err)
+	}
+
+	for _, rate := range rates {
+		if !validRate(rate.Rate) {
+			return nil, fmt.Errorf("invalid exchange rate %q from %s to %s", rate.Rate, rate.From, rate.To)
+		}
+	}
+	return rates, nil
+}
+
+// decodeCSV decodes the rates of the CSV with a header row of from,to,rate,effective_at
+func decodeCSV(r io.Reader) ([]Rate, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var rates []Rate
+	// skip the header row
+	for i := 1; i < len(records); i++ {
+		record := records[i]
+		effectiveAt, err := time.Parse(time.RFC3339, record[3])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		rates = append(rates, Rate{
+			From:        record[0],
+			To:          record[1],
+			Rate:        record[2],
+			EffectiveAt: effectiveAt,
+		})
+	}
+	return rates, nil
+}
@@ -0,0 +1,120 @@
+package fx
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testNow = time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+
+// writeRateFile writes the content to a file of the name in a temporary directory
+func writeRateFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	err := os.WriteFile(path, []byte(content), 0644)
+	assert.NoError(t, err)
+	return path
+}
+
+func newTestFileProvider(t *testing.T, path string) *FileProvider {
+	provider, err := NewFileProvider(path)
+	assert.NoError(t, err)
+	provider.now = func() time.Time { return testNow }
+	return provider
+}
+
+func TestFileProvider(t *testing.T) {
+	testCases := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{
+			"JSON",
+			"rates.json",
+			`[
+				{"from": "USD", "to": "TWD", "rate": "29.5", "effective_at": "2022-04-01T00:00:00Z"},
+				{"from": "USD", "to": "TWD", "rate": "30.5", "effective_at": "2022-05-01T00:00:00Z"},
+				{"from": "USD", "to": "TWD", "rate": "31.5", "effective_at": "2022-07-01T00:00:00Z"},
+				{"from": "TWD", "to": "USD", "rate": "0.033", "effective_at": "2022-05-01T00:00:00Z"}
+			]`,
+		},
+		{
+			"CSV",
+			"rates.csv",
+			"from,to,rate,effective_at\n" +
+				"USD,TWD,29.5,2022-04-01T00:00:00Z\n" +
+				"USD,TWD,30.5,2022-05-01T00:00:00Z\n" +
+				"USD,TWD,31.5,2022-07-01T00:00:00Z\n" +
+				"TWD,USD,0.033,2022-05-01T00:00:00Z\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			provider := newTestFileProvider(t, writeRateFile(t, tc.file, tc.content))
+
+			// the latest rate effective before now is used
+			rate, err := provider.Rate(context.Background(), "USD", "TWD")
+			assert.NoError(t, err)
+			assert.Equal(t, "USD", rate.From)
+			assert.Equal(t, "TWD", rate.To)
+			assert.Equal(t, "30.5", rate.Rate)
+			assert.Equal(t, time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC), rate.EffectiveAt.UTC())
+
+			_, err = provider.Rate(context.Background(), "USD", "EUR")
+			assert.ErrorIs(t, err, ErrRateNotFound)
+		})
+	}
+}
+
+func TestFileProviderReload(t *testing.T) {
+	path := writeRateFile(t, "rates.json",
+		`[{"from": "USD", "to": "TWD", "rate": "30.5", "effective_at": "2022-05-01T00:00:00Z"}]`)
+	provider := newTestFileProvider(t, path)
+
+	rate, err := provider.Rate(context.Background(), "USD", "TWD")
+	assert.NoError(t, err)
+	assert.Equal(t, "30.5", rate.Rate)
+
+	// publishing a new file takes effect without restarting
+	err = os.WriteFile(path, []byte(
+		`[{"from": "USD", "to": "TWD", "rate": "31", "effective_at": "2022-05-15T00:00:00Z"}]`), 0644)
+	assert.NoError(t, err)
+
+	rate, err = provider.Rate(context.Background(), "USD", "TWD")
+	assert.NoError(t, err)
+	assert.Equal(t, "31", rate.Rate)
+}
+
+func TestFileProviderInvalidFile(t *testing.T) {
+	_, err := NewFileProvider("rates.txt")
+	assert.Error(t, err)
+
+	testCases := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{"invalid rate", "rates.json", `[{"from": "USD", "to": "TWD", "rate": "-1", "effective_at": "2022-05-01T00:00:00Z"}]`},
+		{"invalid JSON", "rates.json", `{`},
+		{"invalid CSV time", "rates.csv", "from,to,rate,effective_at\nUSD,TWD,30,yesterday\n"},
+		{"missing CSV field", "rates.csv", "from,to,rate,effective_at\nUSD,TWD,30\n"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			provider := newTestFileProvider(t, writeRateFile(t, tc.file, tc.content))
+
+			_, err := provider.Rate(context.Background(), "USD", "TWD")
+			assert.Error(t, err)
+			assert.NotErrorIs(t, err, ErrRateNotFound)
+		})
+	}
+
+	provider := newTestFileProvider(t, filepath.Join(t.TempDir(), "missing.json"))
+	_, err = provider.Rate(context.Background(), "USD", "TWD")
+	assert.Error(t, err)
+}
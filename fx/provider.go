@@ -0,0 +1,53 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	db "github.com/peienxie/go-bank/db/sqlc"
+)
+
+// Supported sources of the RateProvider
+const (
+	SourceDB   = "db"
+	SourceFile = "file"
+)
+
+// ErrRateNotFound is returned when no rate is effective for the currency pair
+var ErrRateNotFound = errors.New("exchange rate not found")
+
+// Rate is the number of To currency units bought by one From currency unit
+type Rate struct {
+	From        string    `json:"from"`
+	To          string    `json:"to"`
+	Rate        string    `json:"rate"`
+	EffectiveAt time.Time `json:"effective_at"`
+}
+
+// RateProvider is an interface for looking up exchange rates
+type RateProvider interface {
+	// Rate returns the latest effective rate from one currency to the other
+	Rate(ctx context.Context, from, to string) (Rate, error)
+}
+
+// NewRateProvider creates a rate provider of the given source.
+// The file source reads rates from the file, the db source reads the exchange_rates table
+func NewRateProvider(source string, file string, store db.Querier) (RateProvider, error) {
+	switch source {
+	case SourceDB:
+		return NewStoreProvider(store), nil
+	case SourceFile:
+		return NewFileProvider(file)
+	default:
+		return nil, fmt.Errorf("unsupported exchange rate source %q", source)
+	}
+}
+
+// validRate checks the rate is a positive number
+func validRate(rate string) bool {
+	r, ok := new(big.Rat).SetString(rate)
+	return ok && r.Sign() > 0
+}
@@ -0,0 +1,39 @@
+package fx
+
+import (
+	"context"
+	"database/sql"
+
+	db "github.com/peienxie/go-bank/db/sqlc"
+)
+
+// StoreProvider provides the exchange rates stored in the exchange_rates table
+type StoreProvider struct {
+	store db.Querier
+}
+
+// NewStoreProvider creates a rate provider reading rates from db
+func NewStoreProvider(store db.Querier) *StoreProvider {
+	return &StoreProvider{store: store}
+}
+
+// Rate returns the latest effective rate of the currency pair in db
+func (p *StoreProvider) Rate(ctx context.Context, from, to string) (Rate, error) {
+	rate, err := p.store.GetExchangeRate(ctx, db.GetExchangeRateParams{
+		FromCurrency: from,
+		ToCurrency:   to,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Rate{}, ErrRateNotFound
+		}
+		return Rate{}, err
+	}
+
+	return Rate{
+		From:        rate.FromCurrency,
+		To:          rate.ToCurrency,
+		Rate:        rate.Rate,
+		EffectiveAt: rate.EffectiveAt,
+	}, nil
+}
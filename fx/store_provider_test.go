@@ -0,0 +1,51 @@
+package fx
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/peienxie/go-bank/db/mock"
+	db "github.com/peienxie/go-bank/db/sqlc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoreProvider(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	exchangeRate := db.ExchangeRate{
+		FromCurrency: "USD",
+		ToCurrency:   "TWD",
+		Rate:         "30.5",
+		EffectiveAt:  testNow,
+	}
+	arg := db.GetExchangeRateParams{FromCurrency: "USD", ToCurrency: "TWD"}
+	store.EXPECT().GetExchangeRate(gomock.Any(), arg).Times(1).Return(exchangeRate, nil)
+	arg = db.GetExchangeRateParams{FromCurrency: "USD", ToCurrency: "EUR"}
+	store.EXPECT().GetExchangeRate(gomock.Any(), arg).Times(1).Return(db.ExchangeRate{}, sql.ErrNoRows)
+
+	provider := NewStoreProvider(store)
+
+	rate, err := provider.Rate(context.Background(), "USD", "TWD")
+	assert.NoError(t, err)
+	assert.Equal(t, Rate{From: "USD", To: "TWD", Rate: "30.5", EffectiveAt: testNow}, rate)
+
+	_, err = provider.Rate(context.Background(), "USD", "EUR")
+	assert.ErrorIs(t, err, ErrRateNotFound)
+}
+
+func TestNewRateProvider(t *testing.T) {
+	provider, err := NewRateProvider(SourceDB, "", nil)
+	assert.NoError(t, err)
+	assert.IsType(t, &StoreProvider{}, provider)
+
+	provider, err = NewRateProvider(SourceFile, "rates.json", nil)
+	assert.NoError(t, err)
+	assert.IsType(t, &FileProvider{}, provider)
+
+	_, err = NewRateProvider("unknown", "", nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,55 @@
+// Package logging builds the structured logger of the service and carries it in contexts,
+// so the logs of one request share its request ID from the HTTP handler down to the db
+package logging
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Formats of the log output
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+)
+
+type contextKey struct{}
+
+// New creates a logger writing to stderr at the level (debug, info, warn, error) in the format
+func New(level, format string) (*zap.Logger, error) {
+	var lvl zapcore.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q", level)
+	}
+
+	config := zap.NewProductionConfig()
+	switch format {
+	case FormatJSON:
+	case FormatConsole:
+		config.Encoding = FormatConsole
+		config.EncoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+	default:
+		return nil, fmt.Errorf("invalid log format %q", format)
+	}
+	config.Level = zap.NewAtomicLevelAt(lvl)
+	config.EncoderConfig.TimeKey = "time"
+	config.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+
+	return config.Build()
+}
+
+// NewContext returns a copy of the context carrying the logger
+func NewContext(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger carried by the context, or the global logger if there's none
+func FromContext(ctx context.Context) *zap.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*zap.Logger); ok {
+		return logger
+	}
+	return zap.L()
+}
@@ -0,0 +1,52 @@
+package logging
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestNew(t *testing.T) {
+	testCases := []struct {
+		name   string
+		level  string
+		format string
+		ok     bool
+	}{
+		{"OK json", "info", FormatJSON, true},
+		{"OK console", "debug", FormatConsole, true},
+		{"invalid level", "verbose", FormatJSON, false},
+		{"invalid format", "info", "xml", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			logger, err := New(tc.level, tc.format)
+			if tc.ok {
+				assert.NoError(t, err)
+				assert.NotNil(t, logger)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestLevel(t *testing.T) {
+	logger, err := New("warn", FormatJSON)
+	assert.NoError(t, err)
+
+	assert.False(t, logger.Core().Enabled(zap.InfoLevel))
+	assert.True(t, logger.Core().Enabled(zap.WarnLevel))
+}
+
+func TestFromContext(t *testing.T) {
+	logger := zap.NewExample()
+
+	ctx := NewContext(context.Background(), logger)
+	assert.Same(t, logger, FromContext(ctx))
+
+	assert.Same(t, zap.L(), FromContext(context.Background()))
+}
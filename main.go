@@ -1,29 +1,217 @@
 package main
 
 import (
+	"context"
 	"database/sql"
-	"log"
+	"encoding/json"
+	"flag"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 
 	_ "github.com/lib/pq"
 	"github.com/peienxie/go-bank/api"
 	"github.com/peienxie/go-bank/config"
+	"github.com/peienxie/go-bank/currency"
 	db "github.com/peienxie/go-bank/db/sqlc"
+	"github.com/peienxie/go-bank/fx"
+	"github.com/peienxie/go-bank/logging"
+	"github.com/peienxie/go-bank/token"
+	"github.com/peienxie/go-bank/tracing"
+	"github.com/peienxie/go-bank/worker"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.uber.org/zap"
+)
+
+// Commands of the gobank binary, the server is run if no command is provided
+const (
+	commandServe     = "serve"
+	commandReconcile = "reconcile"
 )
 
 func main() {
+	flag.Parse()
+
+	// logs until the configured logger is built
+	logger, err := logging.New("info", logging.FormatJSON)
+	if err != nil {
+		panic(err)
+	}
+
 	config, err := config.LoadConfig(".")
 	if err != nil {
-		log.Fatal(err)
+		logger.Fatal("cannot load config", zap.Error(err))
 	}
 
+	configuredLogger, err := logging.New(config.LogLevel, config.LogFormat)
+	if err != nil {
+		logger.Fatal("cannot create logger", zap.Error(err))
+	}
+	logger = configuredLogger
+	zap.ReplaceGlobals(logger)
+
+	tracerProvider, err := tracing.NewTracerProvider(context.Background(), config.TraceExporter, config.TraceOTLPEndpoint)
+	if err != nil {
+		logger.Fatal("cannot create tracer provider", zap.Error(err))
+	}
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
 	conn, err := sql.Open(config.DBDriver, config.DBSource)
 	if err != nil {
-		log.Fatal(err)
+		logger.Fatal("cannot open db", zap.Error(err))
+	}
+	db.RegisterDBStatsMetrics(conn)
+	store := db.NewMetricsStore(db.NewTracingStore(db.NewSQLStore(conn)))
+
+	exitCode := 0
+	switch flag.Arg(0) {
+	case commandServe, "":
+		exitCode = runServer(config, store)
+	case commandReconcile:
+		exitCode = runReconcile(store)
+	default:
+		logger.Fatal("unknown command", zap.String("command", flag.Arg(0)))
+	}
+
+	// flush the spans in the batch, the context is canceled explicitly since os.Exit skips deferred calls
+	ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	if err = tracerProvider.Shutdown(ctx); err != nil {
+		logger.Error("cannot shut down tracer provider", zap.Error(err))
+	}
+	cancel()
+
+	if err = conn.Close(); err != nil {
+		logger.Error("cannot close db", zap.Error(err))
+	}
+
+	// deferred calls don't run on os.Exit, so the logger is flushed explicitly
+	logger.Sync()
+	os.Exit(exitCode)
+}
+
+// runServer serves the HTTP API until a shutdown signal is received,
+// it returns exit code 1 if the server can't be started or stops serving on its own
+func runServer(config config.Config, store db.Store) int {
+	tokenMaker, err := token.NewMaker(config.TokenType, config.TokenSymmetricKey)
+	if err != nil {
+		zap.L().Error("cannot create token maker", zap.Error(err))
+		return 1
+	}
+	currencies, err := loadCurrencies(store)
+	if err != nil {
+		zap.L().Error("cannot load currencies", zap.Error(err))
+		return 1
+	}
+	rates, err := fx.NewRateProvider(config.ExchangeRateSource, config.ExchangeRateFile, store)
+	if err != nil {
+		zap.L().Error("cannot create exchange rate provider", zap.Error(err))
+		return 1
+	}
+	if config.ExchangeRateCacheTTL > 0 {
+		rates = fx.NewCachedProvider(rates, config.ExchangeRateCacheTTL)
+	}
+	server := api.NewServer(config, store, tokenMaker, currencies, rates)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// background workers are stopped along with the server
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	var workers sync.WaitGroup
+	if config.HoldExpiryInterval > 0 {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			worker.NewHoldExpirer(store, config.HoldExpiryInterval).Run(workerCtx)
+		}()
+	}
+	if config.SchedulerInterval > 0 {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			worker.NewScheduler(store, config.SchedulerInterval).Run(workerCtx)
+		}()
+	}
+
+	zap.L().Info("serving HTTP", zap.String("address", config.ServerAddress))
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(config.ServerAddress)
+	}()
+
+	// a serve error goes through the same shutdown so the workers are stopped
+	exitCode := 0
+	select {
+	case err = <-serveErr:
+		zap.L().Error("cannot serve", zap.Error(err))
+		exitCode = 1
+	case <-ctx.Done():
+	}
+	// a second signal terminates the process immediately
+	stop()
+	zap.L().Info("shutting down server")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+
+	stopWorkers()
+	if err = server.Shutdown(shutdownCtx); err != nil {
+		zap.L().Error("cannot shut down server gracefully", zap.Error(err))
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-shutdownCtx.Done():
+		zap.L().Error("background workers did not stop before the shutdown timeout")
+	}
+	return exitCode
+}
+
+// runReconcile prints the reconciliation report as JSON,
+// it returns exit code 1 if any discrepancy is found or the reconciliation fails
+func runReconcile(store db.Store) int {
+	report, err := store.Reconcile(context.Background())
+	if err != nil {
+		zap.L().Error("cannot reconcile", zap.Error(err))
+		return 1
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err = encoder.Encode(report); err != nil {
+		zap.L().Error("cannot encode reconciliation report", zap.Error(err))
+		return 1
+	}
+
+	if !report.OK() {
+		return 1
+	}
+	return 0
+}
+
+// loadCurrencies builds the currency registry from the currencies table
+func loadCurrencies(store db.Store) (*currency.Registry, error) {
+	rows, err := store.ListCurrencies(context.Background())
+	if err != nil {
+		return nil, err
 	}
-	store := db.NewSQLStore(conn)
-	server := api.NewServer(store)
 
-	if err = server.Serve(config.ServerAddress); err != nil {
-		log.Fatal(err)
+	currencies := make([]currency.Currency, len(rows))
+	for i, row := range rows {
+		currencies[i] = currency.Currency{
+			Code:     row.Code,
+			Exponent: row.Exponent,
+			Symbol:   row.Symbol,
+		}
 	}
+	return currency.NewRegistry(currencies...), nil
 }
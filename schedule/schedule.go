@@ -0,0 +1,97 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MinInterval is the shortest interval of an @every schedule
+const MinInterval = time.Minute
+
+// ErrInvalidSchedule is returned when the schedule spec can't be parsed
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// Schedule describes when a recurring job runs.
+// Calendar based schedules add days or months, so they keep the time of day across DST changes
+type Schedule struct {
+	months   int
+	days     int
+	interval time.Duration
+}
+
+// descriptors are the predefined cron-like schedules
+var descriptors = map[string]Schedule{
+	"@hourly":  {interval: time.Hour},
+	"@daily":   {days: 1},
+	"@weekly":  {days: 7},
+	"@monthly": {months: 1},
+	"@yearly":  {months: 12},
+}
+
+// Parse parses a schedule spec, which is either one of @hourly, @daily, @weekly,
+// @monthly and @yearly, or @every followed by a duration like "@every 36h"
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if s, ok := descriptors[spec]; ok {
+		return s, nil
+	}
+
+	if strings.HasPrefix(spec, "@every ") {
+		interval, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil {
+			return Schedule{}, fmt.Errorf("%w %q: %v", ErrInvalidSchedule, spec, err)
+		}
+		if interval < MinInterval {
+			return Schedule{}, fmt.Errorf("%w %q: interval must be at least %s", ErrInvalidSchedule, spec, MinInterval)
+		}
+		return Schedule{interval: interval}, nil
+	}
+
+	return Schedule{}, fmt.Errorf("%w %q", ErrInvalidSchedule, spec)
+}
+
+// Next returns the run time following t.
+// Adding months to the end of a month is clamped to the last day of the target month
+func (s Schedule) Next(t time.Time) time.Time {
+	return s.nth(t, 1)
+}
+
+// NextAfter returns the first run time of the schedule started at start which is after now,
+// so the runs missed while nothing was running are skipped.
+// Every run is counted from the start instead of the run before it, so a monthly schedule
+// started on the 31st is clamped to the end of the shorter months and is back on the 31st after them
+func (s Schedule) NextAfter(start, now time.Time) time.Time {
+	n := 1
+	if s.months == 0 && s.days == 0 && now.After(start) {
+		// a fixed interval skips straight to the runs around now
+		n = int(now.Sub(start) / s.interval)
+	}
+	for {
+		if next := s.nth(start, n); next.After(now) {
+			return next
+		}
+		n++
+	}
+}
+
+// nth returns the nth run time of the schedule started at start
+func (s Schedule) nth(start time.Time, n int) time.Time {
+	return addMonths(start, n*s.months).AddDate(0, 0, n*s.days).Add(time.Duration(n) * s.interval)
+}
+
+// addMonths adds n months to t without overflowing into the month after
+func addMonths(t time.Time, n int) time.Time {
+	if n == 0 {
+		return t
+	}
+	year, month, day := t.Date()
+	hour, min, sec := t.Clock()
+	// day 0 of the month after is the last day of the target month
+	lastDay := time.Date(year, month+time.Month(n)+1, 0, 0, 0, 0, 0, t.Location()).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(year, month+time.Month(n), day, hour, min, sec, t.Nanosecond(), t.Location())
+}
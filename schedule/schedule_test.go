@@ -0,0 +1,102 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	start := time.Date(2022, time.January, 31, 9, 30, 0, 0, time.UTC)
+
+	testCases := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{"@hourly", start.Add(time.Hour)},
+		{"@daily", time.Date(2022, time.February, 1, 9, 30, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2022, time.February, 7, 9, 30, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2022, time.February, 28, 9, 30, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2023, time.January, 31, 9, 30, 0, 0, time.UTC)},
+		{"@every 36h", start.Add(36 * time.Hour)},
+		{" @every 90m ", start.Add(90 * time.Minute)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.spec, func(t *testing.T) {
+			s, err := Parse(tc.spec)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, s.Next(start))
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, spec := range []string{"", "@sometimes", "0 9 * * *", "@every", "@every tomorrow", "@every 30s", "@every -1h"} {
+		t.Run(spec, func(t *testing.T) {
+			_, err := Parse(spec)
+			assert.ErrorIs(t, err, ErrInvalidSchedule)
+		})
+	}
+}
+
+func TestNextAfter(t *testing.T) {
+	s, err := Parse("@daily")
+	assert.NoError(t, err)
+
+	start := time.Date(2022, time.January, 1, 9, 0, 0, 0, time.UTC)
+	now := time.Date(2022, time.January, 5, 12, 0, 0, 0, time.UTC)
+
+	// the missed runs are skipped
+	assert.Equal(t, time.Date(2022, time.January, 6, 9, 0, 0, 0, time.UTC), s.NextAfter(start, now))
+	// the next run is used if it's still ahead
+	assert.Equal(t, time.Date(2022, time.January, 2, 9, 0, 0, 0, time.UTC), s.NextAfter(start, start))
+}
+
+func TestNextAfterKeepsDayOfMonth(t *testing.T) {
+	s, err := Parse("@monthly")
+	assert.NoError(t, err)
+
+	start := time.Date(2022, time.January, 31, 9, 0, 0, 0, time.UTC)
+	expected := []time.Time{
+		time.Date(2022, time.February, 28, 9, 0, 0, 0, time.UTC),
+		time.Date(2022, time.March, 31, 9, 0, 0, 0, time.UTC),
+		time.Date(2022, time.April, 30, 9, 0, 0, 0, time.UTC),
+		time.Date(2022, time.May, 31, 9, 0, 0, 0, time.UTC),
+		time.Date(2022, time.June, 30, 9, 0, 0, 0, time.UTC),
+	}
+
+	// every run is scheduled right after the one before it, the clamp doesn't carry over
+	run := start
+	for _, next := range expected {
+		run = s.NextAfter(start, run)
+		assert.Equal(t, next, run)
+	}
+}
+
+func TestNextAfterInterval(t *testing.T) {
+	s, err := Parse("@every 90m")
+	assert.NoError(t, err)
+
+	start := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	now := start.Add(365*24*time.Hour + time.Minute)
+
+	next := s.NextAfter(start, now)
+	assert.True(t, next.After(now))
+	assert.False(t, next.Add(-90*time.Minute).After(now))
+	assert.Zero(t, next.Sub(start)%(90*time.Minute))
+	// a run exactly now is already done
+	assert.Equal(t, start.Add(3*time.Hour), s.NextAfter(start, start.Add(90*time.Minute)))
+}
+
+func TestDailyKeepsTimeOfDayAcrossDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone database is not available")
+	}
+	s, err := Parse("@daily")
+	assert.NoError(t, err)
+
+	before := time.Date(2022, time.March, 12, 9, 0, 0, 0, loc)
+	assert.Equal(t, time.Date(2022, time.March, 13, 9, 0, 0, 0, loc), s.Next(before))
+}
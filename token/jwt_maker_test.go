@@ -0,0 +1,90 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestJWTMaker makes sure a created token can be verified
+func TestJWTMaker(t *testing.T) {
+	maker, err := NewJWTMaker(randomString(32))
+	assert.NoError(t, err)
+
+	username := randomUsername()
+	duration := time.Minute
+
+	issuedAt := time.Now()
+	expiredAt := issuedAt.Add(duration)
+
+	token, createdPayload, err := maker.CreateToken(username, AudienceAccess, duration)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, token)
+	assert.NotEmpty(t, createdPayload)
+
+	payload, err := maker.VerifyToken(token, AudienceAccess)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, payload)
+
+	assert.Equal(t, createdPayload.ID, payload.ID)
+	assert.Equal(t, username, payload.Username)
+	assert.Equal(t, AudienceAccess, payload.Audience)
+	assert.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
+	assert.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
+}
+
+// TestJWTTokenAudience makes sure a token is rejected by another audience
+func TestJWTTokenAudience(t *testing.T) {
+	maker, err := NewJWTMaker(randomString(32))
+	assert.NoError(t, err)
+
+	token, _, err := maker.CreateToken(randomUsername(), AudienceRefresh, time.Minute)
+	assert.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token, AudienceAccess)
+	assert.Error(t, err)
+	assert.EqualError(t, err, ErrInvalidToken.Error())
+	assert.Nil(t, payload)
+}
+
+// TestExpiredJWTToken makes sure an expired token is rejected
+func TestExpiredJWTToken(t *testing.T) {
+	maker, err := NewJWTMaker(randomString(32))
+	assert.NoError(t, err)
+
+	token, _, err := maker.CreateToken(randomUsername(), AudienceAccess, -time.Minute)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, token)
+
+	payload, err := maker.VerifyToken(token, AudienceAccess)
+	assert.Error(t, err)
+	assert.EqualError(t, err, ErrExpiredToken.Error())
+	assert.Nil(t, payload)
+}
+
+// TestInvalidJWTTokenAlgNone makes sure an unsigned token is rejected
+func TestInvalidJWTTokenAlgNone(t *testing.T) {
+	payload, err := NewPayload(randomUsername(), AudienceAccess, time.Minute)
+	assert.NoError(t, err)
+
+	jwtToken := jwt.NewWithClaims(jwt.SigningMethodNone, payload)
+	token, err := jwtToken.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	assert.NoError(t, err)
+
+	maker, err := NewJWTMaker(randomString(32))
+	assert.NoError(t, err)
+
+	payload, err = maker.VerifyToken(token, AudienceAccess)
+	assert.Error(t, err)
+	assert.EqualError(t, err, ErrInvalidToken.Error())
+	assert.Nil(t, payload)
+}
+
+// TestInvalidJWTKeySize makes sure the secret key size is checked
+func TestInvalidJWTKeySize(t *testing.T) {
+	maker, err := NewJWTMaker(randomString(10))
+	assert.Error(t, err)
+	assert.Nil(t, maker)
+}
@@ -0,0 +1,33 @@
+package token
+
+import (
+	"fmt"
+	"time"
+)
+
+// Supported token types of the Maker
+const (
+	TypePaseto = "paseto"
+	TypeJWT    = "jwt"
+)
+
+// Maker is an interface for managing tokens
+type Maker interface {
+	// CreateToken creates a new token for a specific username, audience and duration
+	CreateToken(username string, audience string, duration time.Duration) (string, *Payload, error)
+
+	// VerifyToken checks if the token is valid and issued for the audience
+	VerifyToken(token string, audience string) (*Payload, error)
+}
+
+// NewMaker creates a token maker of the given type with the symmetric key
+func NewMaker(tokenType string, symmetricKey string) (Maker, error) {
+	switch tokenType {
+	case TypePaseto:
+		return NewPasetoMaker(symmetricKey)
+	case TypeJWT:
+		return NewJWTMaker(symmetricKey)
+	default:
+		return nil, fmt.Errorf("unsupported token type %q", tokenType)
+	}
+}
@@ -0,0 +1,24 @@
+package token
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewMaker makes sure the maker is created by the configured token type
+func TestNewMaker(t *testing.T) {
+	key := randomString(32)
+
+	maker, err := NewMaker(TypePaseto, key)
+	assert.NoError(t, err)
+	assert.IsType(t, &PasetoMaker{}, maker)
+
+	maker, err = NewMaker(TypeJWT, key)
+	assert.NoError(t, err)
+	assert.IsType(t, &JWTMaker{}, maker)
+
+	maker, err = NewMaker("unknown", key)
+	assert.Error(t, err)
+	assert.Nil(t, maker)
+}
@@ -0,0 +1,61 @@
+package token
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aead/chacha20poly1305"
+	"github.com/o1egl/paseto"
+)
+
+// PasetoMaker is a PASETO v2 local token maker
+type PasetoMaker struct {
+	paseto       *paseto.V2
+	symmetricKey []byte
+}
+
+// NewPasetoMaker creates a new PasetoMaker with the provided symmetric key
+func NewPasetoMaker(symmetricKey string) (Maker, error) {
+	if len(symmetricKey) != chacha20poly1305.KeySize {
+		return nil, fmt.Errorf("invalid key size: must be exactly %d characters", chacha20poly1305.KeySize)
+	}
+
+	maker := &PasetoMaker{
+		paseto:       paseto.NewV2(),
+		symmetricKey: []byte(symmetricKey),
+	}
+	return maker, nil
+}
+
+// CreateToken creates a new token for a specific username, audience and duration
+func (m *PasetoMaker) CreateToken(username string, audience string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, audience, duration)
+	if err != nil {
+		return "", nil, err
+	}
+
+	token, err := m.paseto.Encrypt(m.symmetricKey, payload, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, payload, nil
+}
+
+// VerifyToken checks if the token is valid and issued for the audience
+func (m *PasetoMaker) VerifyToken(token string, audience string) (*Payload, error) {
+	payload := &Payload{}
+
+	err := m.paseto.Decrypt(token, m.symmetricKey, payload, nil)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if err = payload.Valid(); err != nil {
+		return nil, err
+	}
+
+	if err = payload.checkAudience(audience); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
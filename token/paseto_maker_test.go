@@ -0,0 +1,87 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPasetoMaker makes sure a created token can be verified
+func TestPasetoMaker(t *testing.T) {
+	maker, err := NewPasetoMaker(randomString(32))
+	assert.NoError(t, err)
+
+	username := randomUsername()
+	duration := time.Minute
+
+	issuedAt := time.Now()
+	expiredAt := issuedAt.Add(duration)
+
+	token, createdPayload, err := maker.CreateToken(username, AudienceAccess, duration)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, token)
+	assert.NotEmpty(t, createdPayload)
+
+	payload, err := maker.VerifyToken(token, AudienceAccess)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, payload)
+
+	assert.Equal(t, createdPayload.ID, payload.ID)
+	assert.Equal(t, username, payload.Username)
+	assert.Equal(t, AudienceAccess, payload.Audience)
+	assert.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
+	assert.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
+}
+
+// TestPasetoTokenAudience makes sure a token is rejected by another audience
+func TestPasetoTokenAudience(t *testing.T) {
+	maker, err := NewPasetoMaker(randomString(32))
+	assert.NoError(t, err)
+
+	token, _, err := maker.CreateToken(randomUsername(), AudienceRefresh, time.Minute)
+	assert.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token, AudienceAccess)
+	assert.Error(t, err)
+	assert.EqualError(t, err, ErrInvalidToken.Error())
+	assert.Nil(t, payload)
+}
+
+// TestExpiredPasetoToken makes sure an expired token is rejected
+func TestExpiredPasetoToken(t *testing.T) {
+	maker, err := NewPasetoMaker(randomString(32))
+	assert.NoError(t, err)
+
+	token, _, err := maker.CreateToken(randomUsername(), AudienceAccess, -time.Minute)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, token)
+
+	payload, err := maker.VerifyToken(token, AudienceAccess)
+	assert.Error(t, err)
+	assert.EqualError(t, err, ErrExpiredToken.Error())
+	assert.Nil(t, payload)
+}
+
+// TestInvalidPasetoToken makes sure a token encrypted by other key is rejected
+func TestInvalidPasetoToken(t *testing.T) {
+	maker1, err := NewPasetoMaker(randomString(32))
+	assert.NoError(t, err)
+	maker2, err := NewPasetoMaker(randomString(32))
+	assert.NoError(t, err)
+
+	token, _, err := maker1.CreateToken(randomUsername(), AudienceAccess, time.Minute)
+	assert.NoError(t, err)
+
+	payload, err := maker2.VerifyToken(token, AudienceAccess)
+	assert.Error(t, err)
+	assert.EqualError(t, err, ErrInvalidToken.Error())
+	assert.Nil(t, payload)
+}
+
+// TestInvalidPasetoKeySize makes sure the symmetric key size is checked
+func TestInvalidPasetoKeySize(t *testing.T) {
+	maker, err := NewPasetoMaker(randomString(10))
+	assert.Error(t, err)
+	assert.Nil(t, maker)
+}
@@ -0,0 +1,100 @@
+// Package tracing sets up the OpenTelemetry tracer provider of the service
+// with the span exporter chosen by the configuration
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+)
+
+// Exporters of the spans
+const (
+	// ExporterNone drops every span, trace contexts are still propagated
+	ExporterNone = "none"
+	// ExporterStdout writes the spans to stdout as JSON lines
+	ExporterStdout = "stdout"
+	// ExporterOTLP sends the spans to an OTLP/HTTP collector
+	ExporterOTLP = "otlp"
+)
+
+// ServiceName identifies the service in the exported spans
+const ServiceName = "gobank"
+
+// otlpTimeout bounds each upload to the collector
+const otlpTimeout = 10 * time.Second
+
+// NewTracerProvider creates a tracer provider exporting spans with the exporter,
+// the OTLP endpoint is only used by the OTLP exporter.
+// The provider must be shut down to flush the spans in the batch
+func NewTracerProvider(ctx context.Context, exporter, otlpEndpoint string) (*sdktrace.TracerProvider, error) {
+	res := resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(ServiceName))
+
+	var spanExporter sdktrace.SpanExporter
+	var err error
+	switch exporter {
+	case ExporterNone:
+		// no span is sampled, the exporter is only there for the provider to shut down cleanly
+		spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(io.Discard))
+		if err != nil {
+			return nil, err
+		}
+		return sdktrace.NewTracerProvider(
+			sdktrace.WithResource(res),
+			sdktrace.WithSampler(sdktrace.NeverSample()),
+			sdktrace.WithSyncer(spanExporter),
+		), nil
+	case ExporterStdout:
+		spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		opts, err = otlpOptions(otlpEndpoint)
+		if err != nil {
+			return nil, err
+		}
+		spanExporter, err = otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unsupported trace exporter %q", exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithResource(res),
+		sdktrace.WithBatcher(spanExporter),
+	), nil
+}
+
+// otlpOptions returns the options of the OTLP/HTTP exporter uploading to the traces endpoint
+// of the collector, e.g. http://localhost:4318/v1/traces
+func otlpOptions(endpoint string) ([]otlptracehttp.Option, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OTLP endpoint: %w", err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q: missing host", endpoint)
+	}
+
+	opts := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(u.Host),
+		otlptracehttp.WithTimeout(otlpTimeout),
+	}
+	if u.Path != "" {
+		opts = append(opts, otlptracehttp.WithURLPath(u.Path))
+	}
+	if u.Scheme == "http" {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	return opts, nil
+}
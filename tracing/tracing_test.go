@@ -0,0 +1,68 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestNewTracerProvider(t *testing.T) {
+	testCases := []struct {
+		name     string
+		exporter string
+		endpoint string
+		ok       bool
+	}{
+		{"none", ExporterNone, "", true},
+		{"stdout", ExporterStdout, "", true},
+		{"otlp", ExporterOTLP, "http://localhost:4318/v1/traces", true},
+		{"otlp invalid endpoint", ExporterOTLP, "localhost", false},
+		{"unsupported", "zipkin", "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tp, err := NewTracerProvider(context.Background(), tc.exporter, tc.endpoint)
+			if !tc.ok {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NoError(t, tp.Shutdown(context.Background()))
+		})
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	received := make(chan *tracepb.TracesData, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		var data tracepb.TracesData
+		assert.NoError(t, proto.Unmarshal(body, &data))
+		received <- &data
+	}))
+	defer collector.Close()
+
+	tp, err := NewTracerProvider(context.Background(), ExporterOTLP, collector.URL+"/v1/traces")
+	assert.NoError(t, err)
+
+	_, span := tp.Tracer("test").Start(context.Background(), "span")
+	span.End()
+	assert.NoError(t, tp.Shutdown(context.Background()))
+
+	data := <-received
+	assert.Len(t, data.ResourceSpans, 1)
+	spans := data.ResourceSpans[0].ScopeSpans[0].Spans
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "span", spans[0].Name)
+}
@@ -0,0 +1,47 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	db "github.com/peienxie/go-bank/db/sqlc"
+	"github.com/peienxie/go-bank/logging"
+	"go.uber.org/zap"
+)
+
+// HoldExpirer marks the active holds past their expiry time expired in the background of the server process.
+// Expired holds stop reserving money as soon as they expire, so it only keeps their status accurate
+type HoldExpirer struct {
+	store    db.Store
+	interval time.Duration
+}
+
+// NewHoldExpirer creates a hold expirer expiring the holds every interval
+func NewHoldExpirer(store db.Store, interval time.Duration) *HoldExpirer {
+	return &HoldExpirer{
+		store:    store,
+		interval: interval,
+	}
+}
+
+// Run expires the holds every interval until the context is done
+func (e *HoldExpirer) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := e.store.ExpireHolds(ctx)
+			if err != nil {
+				logging.FromContext(ctx).Error("cannot expire holds", zap.Error(err))
+				continue
+			}
+			if n > 0 {
+				logging.FromContext(ctx).Info("expired holds", zap.Int64("count", n))
+			}
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/peienxie/go-bank/db/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHoldExpirerRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := mockdb.NewMockStore(ctrl)
+	calls := 0
+	// a failed run doesn't stop the expirer, the ticker may still fire once more while it's being stopped
+	store.EXPECT().ExpireHolds(gomock.Any()).MinTimes(2).DoAndReturn(func(context.Context) (int64, error) {
+		calls++
+		if calls == 1 {
+			return 0, sql.ErrConnDone
+		}
+		cancel()
+		return 3, nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		NewHoldExpirer(store, time.Millisecond).Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("hold expirer did not stop after the context is done")
+	}
+	assert.GreaterOrEqual(t, calls, 2)
+}
@@ -0,0 +1,131 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	db "github.com/peienxie/go-bank/db/sqlc"
+	"github.com/peienxie/go-bank/logging"
+	"github.com/peienxie/go-bank/schedule"
+	"go.uber.org/zap"
+)
+
+// Scheduler runs the due scheduled transfers in the background of the server process.
+// Several schedulers can run against the same db, each scheduled transfer is locked by one of them at a time
+type Scheduler struct {
+	store    db.Store
+	interval time.Duration
+	now      func() time.Time
+}
+
+// NewScheduler creates a scheduler checking for due scheduled transfers every interval
+func NewScheduler(store db.Store, interval time.Duration) *Scheduler {
+	return &Scheduler{
+		store:    store,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+// Run runs the due scheduled transfers every interval until the context is done
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.RunDue(ctx)
+			if err != nil {
+				logging.FromContext(ctx).Error("cannot run scheduled transfers", zap.Error(err))
+			}
+			if n > 0 {
+				logging.FromContext(ctx).Info("ran scheduled transfers", zap.Int("count", n))
+			}
+		}
+	}
+}
+
+// RunDue runs the scheduled transfers which are due one by one until none is left,
+// and returns the number of scheduled transfers it ran
+func (s *Scheduler) RunDue(ctx context.Context) (int, error) {
+	for n := 0; ; n++ {
+		// stop claiming scheduled transfers once the scheduler is stopped
+		if ctx.Err() != nil {
+			return n, nil
+		}
+
+		err := s.store.ScheduledTransferTx(ctx, func(q db.ScheduledTransferQuerier, scheduled db.ScheduledTransfer) error {
+			return s.run(ctx, q, scheduled)
+		})
+		if err == sql.ErrNoRows {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+	}
+}
+
+// run executes the scheduled transfer, records the outcome and schedules its next run.
+// A failed transfer is recorded as a failed run and doesn't stop the schedule.
+// The transfer is made in the transaction of the run, so it's rolled back if the run
+// can't be recorded and the scheduled transfer is run again instead of paying twice
+func (s *Scheduler) run(ctx context.Context, q db.ScheduledTransferQuerier, scheduled db.ScheduledTransfer) error {
+	now := s.now()
+	// the logs of the transfer are traced back to the scheduled transfer
+	ctx = logging.NewContext(ctx, logging.FromContext(ctx).With(zap.Int64("scheduled_transfer_id", scheduled.ID)))
+
+	// the schedule is updated to end before this run
+	if scheduled.EndAt.Valid && scheduled.NextRunAt.Time.After(scheduled.EndAt.Time) {
+		_, err := q.UpdateScheduledTransferNextRun(ctx, db.UpdateScheduledTransferNextRunParams{ID: scheduled.ID})
+		return err
+	}
+
+	result, err := q.TransferTx(ctx, db.TransferTxParams{
+		FromAccountID: scheduled.FromAccountID,
+		ToAccountID:   scheduled.ToAccountID,
+		Amount:        scheduled.Amount,
+	})
+
+	arg := db.CreateScheduledTransferRunParams{
+		ScheduledTransferID: scheduled.ID,
+		Status:              db.ScheduledTransferRunStatusSucceeded,
+	}
+	if err != nil {
+		arg.Status = db.ScheduledTransferRunStatusFailed
+		arg.Error = err.Error()
+	} else {
+		arg.TransferID = sql.NullInt64{Int64: result.Transfer.ID, Valid: true}
+	}
+	if _, err = q.CreateScheduledTransferRun(ctx, arg); err != nil {
+		return err
+	}
+
+	_, err = q.UpdateScheduledTransferNextRun(ctx, db.UpdateScheduledTransferNextRunParams{
+		ID:        scheduled.ID,
+		NextRunAt: nextRun(ctx, scheduled, now),
+	})
+	return err
+}
+
+// nextRun returns the run following the current run of the scheduled transfer,
+// counted from its start so a clamped month end doesn't move the later runs.
+// It's null if the schedule ends before the next run
+func nextRun(ctx context.Context, scheduled db.ScheduledTransfer, now time.Time) sql.NullTime {
+	sched, err := schedule.Parse(scheduled.Schedule)
+	if err != nil {
+		// the schedule is validated when it's saved, stop the broken one instead of running it forever
+		logging.FromContext(ctx).Error("cannot parse schedule", zap.Error(err))
+		return sql.NullTime{}
+	}
+
+	next := sched.NextAfter(scheduled.StartAt, now)
+	if scheduled.EndAt.Valid && next.After(scheduled.EndAt.Time) {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: next, Valid: true}
+}
@@ -0,0 +1,249 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/peienxie/go-bank/db/mock"
+	db "github.com/peienxie/go-bank/db/sqlc"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestScheduler creates a scheduler whose clock is fixed at now
+func newTestScheduler(store db.Store, now time.Time) *Scheduler {
+	s := NewScheduler(store, time.Minute)
+	s.now = func() time.Time { return now }
+	return s
+}
+
+// expectScheduledTransfers makes ScheduledTransferTx hand out the scheduled transfers
+// in order with the store as the queries of the transaction, and then report nothing is due
+func expectScheduledTransfers(store *mockdb.MockStore, scheduled ...db.ScheduledTransfer) {
+	calls := 0
+	store.EXPECT().ScheduledTransferTx(gomock.Any(), gomock.Any()).Times(len(scheduled) + 1).
+		DoAndReturn(func(_ context.Context, fn func(db.ScheduledTransferQuerier, db.ScheduledTransfer) error) error {
+			if calls == len(scheduled) {
+				return sql.ErrNoRows
+			}
+			calls++
+			return fn(store, scheduled[calls-1])
+		})
+}
+
+func TestSchedulerRunDue(t *testing.T) {
+	now := time.Date(2022, time.March, 1, 9, 30, 0, 0, time.UTC)
+	scheduled := db.ScheduledTransfer{
+		ID:            1,
+		FromAccountID: 10,
+		ToAccountID:   20,
+		Amount:        100,
+		Schedule:      "@daily",
+		StartAt:       now.Add(-30 * time.Minute),
+		NextRunAt:     sql.NullTime{Time: now.Add(-30 * time.Minute), Valid: true},
+	}
+	nextRunAt := sql.NullTime{Time: scheduled.NextRunAt.Time.AddDate(0, 0, 1), Valid: true}
+
+	testCases := []struct {
+		name       string
+		scheduled  db.ScheduledTransfer
+		buildStubs func(store *mockdb.MockStore, scheduled db.ScheduledTransfer)
+	}{
+		{
+			"Succeeded",
+			scheduled,
+			func(store *mockdb.MockStore, scheduled db.ScheduledTransfer) {
+				store.EXPECT().TransferTx(gomock.Any(), db.TransferTxParams{
+					FromAccountID: scheduled.FromAccountID,
+					ToAccountID:   scheduled.ToAccountID,
+					Amount:        scheduled.Amount,
+				}).Times(1).Return(db.TransferTxResult{Transfer: db.Transfer{ID: 5}}, nil)
+				store.EXPECT().CreateScheduledTransferRun(gomock.Any(), db.CreateScheduledTransferRunParams{
+					ScheduledTransferID: scheduled.ID,
+					Status:              db.ScheduledTransferRunStatusSucceeded,
+					TransferID:          sql.NullInt64{Int64: 5, Valid: true},
+				}).Times(1)
+				store.EXPECT().UpdateScheduledTransferNextRun(gomock.Any(), db.UpdateScheduledTransferNextRunParams{
+					ID:        scheduled.ID,
+					NextRunAt: nextRunAt,
+				}).Times(1)
+			},
+		},
+		{
+			"Failed transfer is recorded and rescheduled",
+			scheduled,
+			func(store *mockdb.MockStore, scheduled db.ScheduledTransfer) {
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.TransferTxResult{}, db.ErrInsufficientFunds)
+				store.EXPECT().CreateScheduledTransferRun(gomock.Any(), db.CreateScheduledTransferRunParams{
+					ScheduledTransferID: scheduled.ID,
+					Status:              db.ScheduledTransferRunStatusFailed,
+					Error:               db.ErrInsufficientFunds.Error(),
+				}).Times(1)
+				store.EXPECT().UpdateScheduledTransferNextRun(gomock.Any(), db.UpdateScheduledTransferNextRunParams{
+					ID:        scheduled.ID,
+					NextRunAt: nextRunAt,
+				}).Times(1)
+			},
+		},
+		{
+			"Last run ends the schedule",
+			func() db.ScheduledTransfer {
+				s := scheduled
+				s.EndAt = sql.NullTime{Time: now.Add(time.Hour), Valid: true}
+				return s
+			}(),
+			func(store *mockdb.MockStore, scheduled db.ScheduledTransfer) {
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().CreateScheduledTransferRun(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().UpdateScheduledTransferNextRun(gomock.Any(), db.UpdateScheduledTransferNextRunParams{
+					ID: scheduled.ID,
+				}).Times(1)
+			},
+		},
+		{
+			"Ended schedule is not run",
+			func() db.ScheduledTransfer {
+				s := scheduled
+				s.EndAt = sql.NullTime{Time: now.Add(-time.Hour), Valid: true}
+				return s
+			}(),
+			func(store *mockdb.MockStore, scheduled db.ScheduledTransfer) {
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateScheduledTransferRun(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().UpdateScheduledTransferNextRun(gomock.Any(), db.UpdateScheduledTransferNextRunParams{
+					ID: scheduled.ID,
+				}).Times(1)
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			expectScheduledTransfers(store, tc.scheduled)
+			tc.buildStubs(store, tc.scheduled)
+
+			n, err := newTestScheduler(store, now).RunDue(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, 1, n)
+		})
+	}
+}
+
+// txQuerier emulates the transaction of a scheduled transfer run,
+// the transfers made through it are committed only if the run succeeds
+type txQuerier struct {
+	*mockdb.MockStore
+	pending []db.TransferTxResult
+}
+
+func (q *txQuerier) TransferTx(ctx context.Context, arg db.TransferTxParams) (db.TransferTxResult, error) {
+	result, err := q.MockStore.TransferTx(ctx, arg)
+	if err == nil {
+		q.pending = append(q.pending, result)
+	}
+	return result, err
+}
+
+// TestSchedulerRunDueRecordFailed makes sure the transfer is rolled back along with the run
+// if the run can't be recorded, so running the scheduled transfer again doesn't pay twice
+func TestSchedulerRunDueRecordFailed(t *testing.T) {
+	now := time.Date(2022, time.March, 1, 9, 30, 0, 0, time.UTC)
+	scheduled := db.ScheduledTransfer{
+		ID:            1,
+		FromAccountID: 10,
+		ToAccountID:   20,
+		Amount:        100,
+		Schedule:      "@daily",
+		StartAt:       now.Add(-30 * time.Minute),
+		NextRunAt:     sql.NullTime{Time: now.Add(-30 * time.Minute), Valid: true},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	var committed []db.TransferTxResult
+	store.EXPECT().ScheduledTransferTx(gomock.Any(), gomock.Any()).AnyTimes().
+		DoAndReturn(func(_ context.Context, fn func(db.ScheduledTransferQuerier, db.ScheduledTransfer) error) error {
+			// the scheduled transfer isn't due anymore once a run is committed
+			if len(committed) > 0 {
+				return sql.ErrNoRows
+			}
+			tx := &txQuerier{MockStore: store}
+			if err := fn(tx, scheduled); err != nil {
+				return err
+			}
+			committed = append(committed, tx.pending...)
+			return nil
+		})
+	gomock.InOrder(
+		store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).
+			Return(db.TransferTxResult{Transfer: db.Transfer{ID: 5}}, nil),
+		store.EXPECT().CreateScheduledTransferRun(gomock.Any(), gomock.Any()).Times(1).
+			Return(db.ScheduledTransferRun{}, sql.ErrConnDone),
+		store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).
+			Return(db.TransferTxResult{Transfer: db.Transfer{ID: 6}}, nil),
+		store.EXPECT().CreateScheduledTransferRun(gomock.Any(), gomock.Any()).Times(1),
+		store.EXPECT().UpdateScheduledTransferNextRun(gomock.Any(), gomock.Any()).Times(1),
+	)
+
+	scheduler := newTestScheduler(store, now)
+	n, err := scheduler.RunDue(context.Background())
+	assert.ErrorIs(t, err, sql.ErrConnDone)
+	assert.Zero(t, n)
+	assert.Empty(t, committed)
+
+	n, err = scheduler.RunDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Len(t, committed, 1)
+	assert.Equal(t, int64(6), committed[0].Transfer.ID)
+}
+
+func TestSchedulerRunDueError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ScheduledTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(sql.ErrConnDone)
+
+	n, err := newTestScheduler(store, time.Now()).RunDue(context.Background())
+	assert.ErrorIs(t, err, sql.ErrConnDone)
+	assert.Zero(t, n)
+}
+
+func TestNextRun(t *testing.T) {
+	now := time.Date(2022, time.March, 10, 12, 0, 0, 0, time.UTC)
+	scheduled := db.ScheduledTransfer{
+		Schedule:  "@weekly",
+		StartAt:   time.Date(2022, time.February, 22, 9, 0, 0, 0, time.UTC),
+		NextRunAt: sql.NullTime{Time: time.Date(2022, time.March, 1, 9, 0, 0, 0, time.UTC), Valid: true},
+	}
+
+	// the missed run of March 8 is skipped
+	assert.Equal(t, sql.NullTime{Time: time.Date(2022, time.March, 15, 9, 0, 0, 0, time.UTC), Valid: true}, nextRun(context.Background(), scheduled, now))
+
+	scheduled.EndAt = sql.NullTime{Time: time.Date(2022, time.March, 14, 0, 0, 0, 0, time.UTC), Valid: true}
+	assert.False(t, nextRun(context.Background(), scheduled, now).Valid)
+
+	scheduled.Schedule = "invalid"
+	assert.False(t, nextRun(context.Background(), scheduled, now).Valid)
+}
+
+func TestNextRunMonthEnd(t *testing.T) {
+	now := time.Date(2022, time.February, 28, 9, 0, 0, 0, time.UTC)
+	scheduled := db.ScheduledTransfer{
+		Schedule:  "@monthly",
+		StartAt:   time.Date(2022, time.January, 31, 9, 0, 0, 0, time.UTC),
+		NextRunAt: sql.NullTime{Time: now, Valid: true},
+	}
+
+	// the run of February is clamped but March is back on the 31st
+	assert.Equal(t, sql.NullTime{Time: time.Date(2022, time.March, 31, 9, 0, 0, 0, time.UTC), Valid: true}, nextRun(context.Background(), scheduled, now))
+}